@@ -4,5 +4,5 @@ import (
 	"embed"
 )
 
-//go:embed "emails"
+//go:embed "emails" "migrations"
 var EmbeddedFiles embed.FS
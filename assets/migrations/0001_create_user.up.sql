@@ -1,14 +1,12 @@
-CREATE SCHEMA `go-api-starter`;
-USE `go-api-starter`;
-
 CREATE TABLE user (
     id CHAR(36) NOT NULL,
     email VARCHAR(255) NOT NULL,
     password_hash VARCHAR(255) NOT NULL,
     first_name text NOT NULL,
     last_name text NOT NULL,
+    activated BOOLEAN NOT NULL DEFAULT FALSE,
     date_joined DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     PRIMARY KEY (id),
     UNIQUE INDEX uc_email (email)
-);
\ No newline at end of file
+);
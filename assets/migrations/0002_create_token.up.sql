@@ -0,0 +1,9 @@
+CREATE TABLE token (
+    hash BINARY(32) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    expiry DATETIME NOT NULL,
+    scope VARCHAR(32) NOT NULL,
+    PRIMARY KEY (hash),
+    INDEX idx_token_user_scope (user_id, scope),
+    CONSTRAINT fk_token_user FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
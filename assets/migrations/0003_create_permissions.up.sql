@@ -0,0 +1,17 @@
+CREATE TABLE permissions (
+    id INT NOT NULL AUTO_INCREMENT,
+    code VARCHAR(64) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX uc_permission_code (code)
+);
+
+CREATE TABLE users_permissions (
+    user_id CHAR(36) NOT NULL,
+    permission_id INT NOT NULL,
+    PRIMARY KEY (user_id, permission_id),
+    CONSTRAINT fk_users_permissions_user FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE,
+    CONSTRAINT fk_users_permissions_permission FOREIGN KEY (permission_id) REFERENCES permissions (id) ON DELETE CASCADE
+);
+
+INSERT INTO permissions (code)
+VALUES ('documents:read'), ('documents:write'), ('permissions:admin');
@@ -0,0 +1,12 @@
+CREATE TABLE refresh_token (
+    hash BINARY(32) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    family_id CHAR(36) NOT NULL,
+    expiry DATETIME NOT NULL,
+    used_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (hash),
+    INDEX idx_refresh_token_family (family_id),
+    INDEX idx_refresh_token_user (user_id),
+    CONSTRAINT fk_refresh_token_user FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
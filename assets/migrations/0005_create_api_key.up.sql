@@ -0,0 +1,15 @@
+CREATE TABLE api_key (
+    id CHAR(36) NOT NULL,
+    prefix CHAR(8) NOT NULL,
+    hash BINARY(32) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    scopes JSON NOT NULL,
+    expiry DATETIME NULL,
+    last_used_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE INDEX uc_api_key_prefix (prefix),
+    INDEX idx_api_key_user (user_id),
+    CONSTRAINT fk_api_key_user FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+CREATE TABLE session (
+    hash BINARY(32) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    csrf_token CHAR(43) NOT NULL,
+    created_at DATETIME NOT NULL,
+    last_activity_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    PRIMARY KEY (hash),
+    INDEX idx_session_user (user_id),
+    INDEX idx_session_expires_at (expires_at),
+    CONSTRAINT fk_session_user FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+ALTER TABLE user
+    ADD COLUMN totp_secret VARCHAR(64) NULL AFTER activated,
+    ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE AFTER totp_secret,
+    ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0 AFTER totp_enabled;
+
+CREATE TABLE recovery_code (
+    hash BINARY(32) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    used_at DATETIME NULL,
+    PRIMARY KEY (hash),
+    INDEX idx_recovery_code_user (user_id),
+    CONSTRAINT fk_recovery_code_user FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+CREATE TABLE outbox (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    topic VARCHAR(64) NOT NULL,
+    payload JSON NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    available_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    dead_at DATETIME NULL,
+    PRIMARY KEY (id),
+    INDEX idx_outbox_available (dead_at, available_at)
+);
@@ -0,0 +1,20 @@
+CREATE TABLE job (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    kind VARCHAR(64) NOT NULL,
+    args JSON NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    unique_key VARCHAR(255) NULL,
+    status ENUM('pending', 'running', 'failed') NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL,
+    last_error TEXT NULL,
+    run_at DATETIME NOT NULL,
+    lease_owner VARCHAR(128) NULL,
+    lease_expires_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    failed_at DATETIME NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX uc_job_unique_key (unique_key),
+    INDEX idx_job_pending (status, run_at, priority),
+    INDEX idx_job_lease (status, lease_expires_at)
+);
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/grocky/go-api-starter/cmd/api/request"
+	"github.com/grocky/go-api-starter/cmd/api/response"
+	"github.com/grocky/go-api-starter/cmd/api/server"
+	"github.com/grocky/go-api-starter/internal/i18n"
+	"github.com/grocky/go-api-starter/internal/mysql"
+	"github.com/grocky/go-api-starter/internal/validator"
+)
+
+// CreateAPIKey creates an API key for the authenticated user. The scopes must
+// be permissions the caller holds, so a key never grants more than the
+// credentials used to create it. The key itself is only returned here.
+func (app *App) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := contextGetUser(r)
+
+	var input struct {
+		Name          string   `json:"name" validate:"required,max=255"`
+		Scopes        []string `json:"scopes" validate:"required,unique"`
+		ExpiresInDays int      `json:"expires_in_days" validate:"min=0,max=3650"`
+	}
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	permissions, err := app.db.GetAllPermissionsForUser(ctx, user.ID)
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	v := validator.New(i18n.FromRequest(r))
+	v.CheckStruct(input)
+	for i, code := range input.Scopes {
+		key := validator.Path("scopes", i)
+
+		if !validator.In(code, mysql.AllPermissions...) {
+			v.AddFieldMessage(key, validator.Msg("must be a known permission").WithCode(validator.CodeInvalidChoice, "values", mysql.AllPermissions))
+			continue
+		}
+
+		v.CheckFieldMessage(permissions.Include(code), key, validator.Msg("must be a permission you hold").WithCode(validator.CodeInvalidChoice))
+	}
+
+	if v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	ttl := time.Duration(input.ExpiresInDays) * 24 * time.Hour
+
+	apiKey, err := app.db.NewAPIKey(ctx, user.ID, input.Name, input.Scopes, ttl)
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	if err := response.JSON(w, http.StatusCreated, map[string]any{"api_key": apiKey}); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+func (app *App) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := app.db.GetAPIKeysForUser(r.Context(), contextGetUser(r).ID)
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, map[string]any{"api_keys": keys}); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+func (app *App) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	var path struct {
+		ID string `path:"id"`
+	}
+
+	v := validator.New(i18n.FromRequest(r))
+	if err := request.DecodePath(r, &path, &v); err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	if err := app.db.DeleteAPIKey(r.Context(), contextGetUser(r).ID, path.ID); err != nil {
+		switch {
+		case errors.Is(err, mysql.ErrRecordNotFound):
+			server.NotFound(w, r)
+		default:
+			server.Error(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
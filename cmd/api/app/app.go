@@ -6,23 +6,67 @@ import (
 	"github.com/grocky/go-api-starter/cmd/api/middleware"
 	"github.com/grocky/go-api-starter/cmd/api/response"
 	"github.com/grocky/go-api-starter/cmd/api/server"
+	"github.com/grocky/go-api-starter/internal/jobs"
+	"github.com/grocky/go-api-starter/internal/jwt"
 	"github.com/grocky/go-api-starter/internal/log"
 	"github.com/grocky/go-api-starter/internal/mysql"
+	"github.com/grocky/go-api-starter/internal/outbox"
+	"github.com/grocky/go-api-starter/internal/password"
+	"github.com/grocky/go-api-starter/internal/session"
+	"github.com/grocky/go-api-starter/internal/smtp"
 	"github.com/grocky/go-api-starter/internal/version"
 	"net/http"
 	"sync"
 )
 
 type App struct {
-	db *mysql.DB
+	db       *mysql.DB
+	mailer   *smtp.Mailer
+	sessions *session.Manager
+	outbox   *outbox.Dispatcher
+	jobs     *jobs.Queue
+	cfg      Config
 	sync.WaitGroup
 	//service go-api-starter.Service
 }
 
-func New(db *mysql.DB) *App {
-	return &App{
-		db: db,
+// Config holds the application settings which are not dependencies.
+type Config struct {
+	PasswordPolicy password.Policy
+
+	// JWT signs and verifies access tokens, which are issued by and for
+	// JWTIssuer.
+	JWT       *jwt.KeySet
+	JWTIssuer string
+
+	// BaseURL is the absolute URL, without a trailing slash, of the site
+	// links in emails point to.
+	BaseURL string
+
+	// SecureCookies restricts session cookies to HTTPS.
+	SecureCookies bool
+
+	// JobConcurrency is the number of background jobs run at once.
+	JobConcurrency int
+}
+
+func New(db *mysql.DB, mailer *smtp.Mailer, cfg Config) *App {
+	app := &App{
+		db:       db,
+		mailer:   mailer,
+		sessions: session.NewManager(db.SessionStore(), cfg.SecureCookies),
+		outbox:   outbox.NewDispatcher(db.OutboxStore()),
+		jobs:     jobs.New(db.JobStore()),
+		cfg:      cfg,
+	}
+
+	app.outbox.Handle(topicEmail, app.deliverEmail)
+
+	if cfg.JobConcurrency > 0 {
+		app.jobs.Concurrency = cfg.JobConcurrency
 	}
+
+	return app
 }
 
 func (app *App) Routes(ctx context.Context) *mux.Router {
@@ -33,10 +77,43 @@ func (app *App) Routes(ctx context.Context) *mux.Router {
 	r.MethodNotAllowedHandler = server.MethodNotAllowedHandler()
 
 	r.Use(middleware.Recovery())
-	r.Use(middleware.PopulateLogger(logger))
 	r.Use(middleware.PopulateRequestID())
+	r.Use(middleware.PopulateLogger(logger))
+	r.Use(middleware.Localize())
+	r.Use(app.ReadYourWrites())
+	r.Use(app.Authenticate())
+	r.Use(app.VerifyCSRF())
 
 	r.HandleFunc("/status", app.Status)
+	r.HandleFunc("/.well-known/jwks.json", app.JWKS).Methods(http.MethodGet)
+
+	r.HandleFunc("/v1/users", app.CreateUser).Methods(http.MethodPost)
+	r.HandleFunc("/v1/users/activated", app.ActivateUser).Methods(http.MethodPut)
+	r.HandleFunc("/v1/users/password", app.UpdateUserPassword).Methods(http.MethodPut)
+
+	r.HandleFunc("/v1/tokens/authentication", app.CreateAuthenticationToken).Methods(http.MethodPost)
+	r.HandleFunc("/v1/tokens/password-reset", app.CreatePasswordResetToken).Methods(http.MethodPost)
+	r.HandleFunc("/v1/tokens/access", app.CreateAccessToken).Methods(http.MethodPost)
+	r.HandleFunc("/v1/tokens/refresh", app.RefreshAccessToken).Methods(http.MethodPost)
+	r.HandleFunc("/v1/tokens/refresh", app.RevokeRefreshToken).Methods(http.MethodDelete)
+
+	r.HandleFunc("/v1/sessions", app.CreateSession).Methods(http.MethodPost)
+	r.Handle("/v1/sessions/current", app.RequireAuthenticatedUser()(http.HandlerFunc(app.GetCurrentSession))).Methods(http.MethodGet)
+	r.HandleFunc("/v1/sessions/current", app.DeleteCurrentSession).Methods(http.MethodDelete)
+
+	owner := app.RequireAccountOwner()
+	r.Handle("/v1/api-keys", owner(http.HandlerFunc(app.ListAPIKeys))).Methods(http.MethodGet)
+	r.Handle("/v1/api-keys", owner(http.HandlerFunc(app.CreateAPIKey))).Methods(http.MethodPost)
+	r.Handle("/v1/api-keys/{id}", owner(http.HandlerFunc(app.RevokeAPIKey))).Methods(http.MethodDelete)
+
+	r.Handle("/v1/users/me/two-factor", owner(http.HandlerFunc(app.EnrollTwoFactor))).Methods(http.MethodPost)
+	r.Handle("/v1/users/me/two-factor", owner(http.HandlerFunc(app.ConfirmTwoFactor))).Methods(http.MethodPut)
+	r.Handle("/v1/users/me/two-factor", owner(http.HandlerFunc(app.DisableTwoFactor))).Methods(http.MethodDelete)
+
+	admin := app.RequirePermission(mysql.PermissionsAdmin)
+	r.Handle("/v1/users/{id}/permissions", admin(http.HandlerFunc(app.ListUserPermissions))).Methods(http.MethodGet)
+	r.Handle("/v1/users/{id}/permissions", admin(http.HandlerFunc(app.GrantUserPermissions))).Methods(http.MethodPost)
+	r.Handle("/v1/users/{id}/permissions/{permission}", admin(http.HandlerFunc(app.RevokeUserPermission))).Methods(http.MethodDelete)
 
 	return r
 }
@@ -46,6 +123,7 @@ func (app *App) Status(w http.ResponseWriter, r *http.Request) {
 
 	dbStats := app.db.Stats()
 	data["dbConnection"] = dbStats
+	data["dbReplicas"] = app.db.ReplicaStats()
 	data["version"] = version.Get()
 
 	if err := response.JSON(w, http.StatusOK, data); err != nil {
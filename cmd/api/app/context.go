@@ -1,3 +1,57 @@
 package app
 
+import (
+	"context"
+	"net/http"
+
+	"github.com/grocky/go-api-starter/internal/mysql"
+	"github.com/grocky/go-api-starter/internal/session"
+)
+
 type contextKey string
+
+const (
+	userContextKey    = contextKey("user")
+	apiKeyContextKey  = contextKey("apiKey")
+	sessionContextKey = contextKey("session")
+)
+
+func contextSetUser(r *http.Request, user *mysql.User) *http.Request {
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	return r.WithContext(ctx)
+}
+
+// contextGetUser returns the user set by the authenticate middleware. It panics
+// when called for a request which did not go through the middleware.
+func contextGetUser(r *http.Request) *mysql.User {
+	user, ok := r.Context().Value(userContextKey).(*mysql.User)
+	if !ok {
+		panic("missing user value in request context")
+	}
+
+	return user
+}
+
+func contextSetAPIKey(r *http.Request, key *mysql.APIKey) *http.Request {
+	ctx := context.WithValue(r.Context(), apiKeyContextKey, key)
+	return r.WithContext(ctx)
+}
+
+// contextGetAPIKey returns the API key which authenticated the request, or nil
+// when the request was not authenticated with an API key.
+func contextGetAPIKey(r *http.Request) *mysql.APIKey {
+	key, _ := r.Context().Value(apiKeyContextKey).(*mysql.APIKey)
+	return key
+}
+
+func contextSetSession(r *http.Request, s *session.Session) *http.Request {
+	ctx := context.WithValue(r.Context(), sessionContextKey, s)
+	return r.WithContext(ctx)
+}
+
+// contextGetSession returns the session which authenticated the request, or
+// nil when the request was not authenticated with a session cookie.
+func contextGetSession(r *http.Request) *session.Session {
+	s, _ := r.Context().Value(sessionContextKey).(*session.Session)
+	return s
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/grocky/go-api-starter/internal/mysql"
+	"github.com/grocky/go-api-starter/internal/smtp"
+)
+
+// topicEmail is the outbox topic of emails, whose payload is an smtp.Message.
+const topicEmail = "email"
+
+// enqueueEmail renders the email and adds it to the outbox within tx, so that
+// it is sent if and only if tx commits. The rendered email, which may contain
+// a token, stays in the outbox until delivered or buried, which drops it.
+func (app *App) enqueueEmail(ctx context.Context, tx *mysql.Tx, recipient string, data any, patterns ...string) error {
+	msg, err := app.mailer.Render(recipient, data, patterns...)
+	if err != nil {
+		return err
+	}
+
+	return tx.EnqueueOutboxMessage(ctx, topicEmail, msg)
+}
+
+// deliverEmail is the outbox handler of emails.
+func (app *App) deliverEmail(ctx context.Context, payload json.RawMessage) error {
+	var msg smtp.Message
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return err
+	}
+
+	return app.mailer.Deliver(ctx, &msg)
+}
+
+// RunOutbox delivers the outbox in the background until ctx is done.
+func (app *App) RunOutbox(ctx context.Context) {
+	app.background(ctx, func() {
+		app.outbox.Run(ctx)
+	})
+}
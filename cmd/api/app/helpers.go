@@ -0,0 +1,28 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grocky/go-api-starter/internal/log"
+)
+
+// background runs fn in a goroutine tracked by the App's WaitGroup, so that
+// shutdown can wait for it to finish. Panics are recovered and logged.
+func (app *App) background(ctx context.Context, fn func()) {
+	logger := log.FromContext(ctx).Named("background")
+
+	app.Add(1)
+
+	go func() {
+		defer app.Done()
+
+		defer func() {
+			if err := recover(); err != nil {
+				logger.Error("background task panic", "panic", fmt.Sprint(err))
+			}
+		}()
+
+		fn()
+	}()
+}
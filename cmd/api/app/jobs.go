@@ -0,0 +1,11 @@
+package app
+
+import "context"
+
+// RunJobs runs the background jobs until ctx is done, then lets the running
+// jobs drain. Shutdown waits for the drain through the App's WaitGroup.
+func (app *App) RunJobs(ctx context.Context) {
+	app.background(ctx, func() {
+		app.jobs.Run(ctx)
+	})
+}
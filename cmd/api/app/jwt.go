@@ -0,0 +1,160 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/grocky/go-api-starter/cmd/api/request"
+	"github.com/grocky/go-api-starter/cmd/api/response"
+	"github.com/grocky/go-api-starter/cmd/api/server"
+	"github.com/grocky/go-api-starter/internal/i18n"
+	"github.com/grocky/go-api-starter/internal/jwt"
+	"github.com/grocky/go-api-starter/internal/log"
+	"github.com/grocky/go-api-starter/internal/mysql"
+	"github.com/grocky/go-api-starter/internal/validator"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// CreateAccessToken exchanges login credentials for a signed JWT access token
+// and a refresh token.
+func (app *App) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var input loginInput
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	user, ok := app.login(w, r, input)
+	if !ok {
+		return
+	}
+
+	refreshToken, err := app.db.NewRefreshToken(ctx, user.ID, refreshTokenTTL)
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	app.writeTokenPair(w, r, http.StatusCreated, user.ID, refreshToken)
+}
+
+// RefreshAccessToken exchanges a refresh token for a new access token and a
+// new refresh token. Refresh tokens are single-use: presenting one a second
+// time revokes every token issued since the login it descends from.
+func (app *App) RefreshAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var input struct {
+		RefreshToken string `json:"refresh_token" validate:"required,min=26,max=26"`
+	}
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	v := validator.New(i18n.FromRequest(r))
+	if v.CheckStruct(input); v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	refreshToken, err := app.db.RotateRefreshToken(ctx, input.RefreshToken, refreshTokenTTL)
+	if err != nil {
+		switch {
+		case errors.Is(err, mysql.ErrRefreshTokenReused):
+			log.FromContext(ctx).Warn("refresh token reuse detected, token family revoked")
+			server.InvalidAuthenticationToken(w, r)
+		case errors.Is(err, mysql.ErrRecordNotFound):
+			server.InvalidAuthenticationToken(w, r)
+		default:
+			server.Error(w, r, err)
+		}
+		return
+	}
+
+	app.writeTokenPair(w, r, http.StatusCreated, refreshToken.UserID, refreshToken)
+}
+
+// RevokeRefreshToken revokes the refresh token and every other token of its
+// family. Access tokens already issued remain valid until they expire.
+func (app *App) RevokeRefreshToken(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" validate:"required,min=26,max=26"`
+	}
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	v := validator.New(i18n.FromRequest(r))
+	if v.CheckStruct(input); v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	if err := app.db.DeleteRefreshTokenFamily(r.Context(), input.RefreshToken); err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// JWKS publishes the public keys used to verify access tokens.
+func (app *App) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	if err := response.JSON(w, http.StatusOK, app.cfg.JWT.JWKS()); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+func (app *App) writeTokenPair(w http.ResponseWriter, r *http.Request, status int, userID string, refreshToken *mysql.RefreshToken) {
+	claims := jwt.NewClaims(app.cfg.JWTIssuer, userID, accessTokenTTL)
+	claims.ID = uuid.NewString()
+
+	accessToken, err := app.cfg.JWT.Sign(claims)
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	data := map[string]any{
+		"access_token":  accessToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(accessTokenTTL.Seconds()),
+		"refresh_token": refreshToken,
+	}
+
+	if err := response.JSON(w, status, data); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+// userForAccessToken returns the user named by the subject of a valid access
+// token. ErrRecordNotFound is returned for invalid tokens.
+func (app *App) userForAccessToken(r *http.Request, token string) (*mysql.User, error) {
+	claims, err := app.cfg.JWT.Verify(token, time.Now())
+	if err != nil {
+		log.FromContext(r.Context()).Debug("invalid access token", "error", err)
+		return nil, mysql.ErrRecordNotFound
+	}
+
+	if claims.Issuer != app.cfg.JWTIssuer || claims.Audience != app.cfg.JWTIssuer {
+		return nil, mysql.ErrRecordNotFound
+	}
+
+	return app.db.GetUser(r.Context(), claims.Subject)
+}
@@ -0,0 +1,212 @@
+package app
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/grocky/go-api-starter/cmd/api/server"
+	"github.com/grocky/go-api-starter/internal/jwt"
+	"github.com/grocky/go-api-starter/internal/mysql"
+	"github.com/grocky/go-api-starter/internal/session"
+)
+
+// apiKeyHeader carries the API key of machine clients.
+const apiKeyHeader = "X-API-Key"
+
+// ReadYourWrites sends the reads of the request to the primary database once
+// the request has written to it, so that handlers read back their own writes
+// rather than a lagging replica.
+func (app *App) ReadYourWrites() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(mysql.WithReadYourWrites(r.Context())))
+		})
+	}
+}
+
+// Authenticate sets the user owning the credentials of the request in the
+// request context, or AnonymousUser when there are none. Credentials are
+// either an API key in the X-API-Key header or a bearer token, which is a JWT
+// access token or an opaque authentication token. Requests carrying both are
+// rejected. Without either, the session cookie of browser clients is used.
+func (app *App) Authenticate() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Authorization")
+			w.Header().Add("Vary", apiKeyHeader)
+			w.Header().Add("Vary", "Cookie")
+
+			authorizationHeader := r.Header.Get("Authorization")
+			apiKey := r.Header.Get(apiKeyHeader)
+
+			var user *mysql.User
+			var err error
+
+			switch {
+			case authorizationHeader == "" && apiKey == "":
+				var sess *session.Session
+				sess, err = app.sessions.Load(r.Context(), w, r)
+				switch {
+				case errors.Is(err, session.ErrNotFound):
+					user, err = mysql.AnonymousUser, nil
+				case err == nil:
+					r = contextSetSession(r, sess)
+					user, err = app.db.GetUser(r.Context(), sess.UserID)
+				}
+			case authorizationHeader != "" && apiKey != "":
+				server.InvalidAuthenticationToken(w, r)
+				return
+			case apiKey != "":
+				var key *mysql.APIKey
+				if key, user, err = app.db.GetAPIKey(r.Context(), apiKey); err == nil {
+					r = contextSetAPIKey(r, key)
+				}
+			default:
+				user, err = app.userForBearerToken(r, authorizationHeader)
+			}
+			if err != nil {
+				switch {
+				case errors.Is(err, mysql.ErrRecordNotFound):
+					server.InvalidAuthenticationToken(w, r)
+				default:
+					server.Error(w, r, err)
+				}
+				return
+			}
+
+			r = contextSetUser(r, user)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// userForBearerToken returns the user owning the bearer token of the
+// Authorization header. ErrRecordNotFound is returned for invalid tokens.
+func (app *App) userForBearerToken(r *http.Request, authorizationHeader string) (*mysql.User, error) {
+	scheme, token, found := strings.Cut(authorizationHeader, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, mysql.ErrRecordNotFound
+	}
+
+	switch {
+	case jwt.LooksLikeJWT(token):
+		return app.userForAccessToken(r, token)
+	case len(token) == 26:
+		return app.db.GetUserForToken(r.Context(), mysql.ScopeAuthentication, token)
+	}
+
+	return nil, mysql.ErrRecordNotFound
+}
+
+// csrfHeader carries the CSRF token of the session on state changing requests.
+const csrfHeader = "X-CSRF-Token"
+
+// VerifyCSRF rejects state changing requests authenticated with a session
+// cookie unless they carry the CSRF token of the session in the X-CSRF-Token
+// header. Browsers attach cookies to cross-site requests, but a cross-site page
+// cannot read the token. Requests authenticated with headers are not affected,
+// as browsers never add those on their own.
+func (app *App) VerifyCSRF() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sess := contextGetSession(r)
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			default:
+				if sess != nil && subtle.ConstantTimeCompare([]byte(r.Header.Get(csrfHeader)), []byte(sess.CSRFToken)) != 1 {
+					server.InvalidCSRFToken(w, r)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAuthenticatedUser rejects anonymous requests.
+func (app *App) RequireAuthenticatedUser() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if contextGetUser(r).IsAnonymous() {
+				server.AuthenticationRequired(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireActivatedUser rejects anonymous requests and requests from users who
+// have not activated their account.
+func (app *App) RequireActivatedUser() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		activated := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !contextGetUser(r).Activated {
+				server.InactiveAccount(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+
+		return app.RequireAuthenticatedUser()(activated)
+	}
+}
+
+// RequireAccountOwner guards account management: it rejects requests
+// authenticated with an API key, whatever its scopes, so that a leaked key
+// cannot manage the keys or the second factor of its owner. The user must be
+// authenticated and activated.
+func (app *App) RequireAccountOwner() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		owner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if contextGetAPIKey(r) != nil {
+				server.APIKeyNotAllowed(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+
+		return app.RequireActivatedUser()(owner)
+	}
+}
+
+// RequirePermission rejects requests from users who do not hold the permission,
+// for example RequirePermission(mysql.PermissionDocumentsWrite). The user must
+// be authenticated and activated. Requests authenticated with an API key also
+// need the permission among the scopes of the key.
+func (app *App) RequirePermission(code string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		permitted := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := contextGetUser(r)
+
+			permissions, err := app.db.GetAllPermissionsForUser(r.Context(), user.ID)
+			if err != nil {
+				server.Error(w, r, err)
+				return
+			}
+
+			if key := contextGetAPIKey(r); key != nil && !key.Scopes.Include(code) {
+				server.NotPermitted(w, r)
+				return
+			}
+
+			if !permissions.Include(code) {
+				server.NotPermitted(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+
+		return app.RequireActivatedUser()(permitted)
+	}
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grocky/go-api-starter/internal/session"
+)
+
+func TestVerifyCSRF(t *testing.T) {
+	ctx := context.Background()
+	sessions := session.NewManager(session.NewMemoryStore(), true)
+
+	sess, err := sessions.Renew(ctx, httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		session    bool
+		bearer     bool
+		csrfToken  string
+		wantStatus int
+	}{
+		{"session with token", http.MethodPost, true, false, sess.CSRFToken, http.StatusOK},
+		{"session without token", http.MethodPost, true, false, "", http.StatusForbidden},
+		{"session with another token", http.MethodPost, true, false, "not-the-token", http.StatusForbidden},
+		{"session delete without token", http.MethodDelete, true, false, "", http.StatusForbidden},
+		{"session read without token", http.MethodGet, true, false, "", http.StatusOK},
+		{"bearer without token", http.MethodPost, false, true, "", http.StatusOK},
+		{"anonymous without token", http.MethodPost, false, false, "", http.StatusOK},
+	}
+
+	app := &App{sessions: sessions}
+	handler := app.VerifyCSRF()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			// Browsers send the cookie along with any request, including
+			// those authenticated with a bearer token.
+			r.AddCookie(&http.Cookie{Name: sessions.CookieName, Value: sess.Token})
+			if tt.csrfToken != "" {
+				r.Header.Set(csrfHeader, tt.csrfToken)
+			}
+			if tt.bearer {
+				r.Header.Set("Authorization", "Bearer token")
+			}
+
+			// Authenticate only sets the session of requests without an
+			// Authorization or X-API-Key header.
+			if tt.session {
+				loaded, err := sessions.Load(ctx, httptest.NewRecorder(), r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r = contextSetSession(r, loaded)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grocky/go-api-starter/cmd/api/request"
+	"github.com/grocky/go-api-starter/cmd/api/response"
+	"github.com/grocky/go-api-starter/cmd/api/server"
+	"github.com/grocky/go-api-starter/internal/i18n"
+	"github.com/grocky/go-api-starter/internal/mysql"
+	"github.com/grocky/go-api-starter/internal/validator"
+)
+
+type userPermissionPath struct {
+	UserID     string `path:"id"`
+	Permission string `path:"permission"`
+}
+
+func (app *App) ListUserPermissions(w http.ResponseWriter, r *http.Request) {
+	var path userPermissionPath
+
+	user, ok := app.readPathUser(w, r, &path)
+	if !ok {
+		return
+	}
+
+	permissions, err := app.db.GetAllPermissionsForUser(r.Context(), user.ID)
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, map[string]any{"permissions": permissions}); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+func (app *App) GrantUserPermissions(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Permissions []string `json:"permissions" validate:"required,unique"`
+	}
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	v := validator.New(i18n.FromRequest(r))
+	v.CheckStruct(input)
+	for i, code := range input.Permissions {
+		v.CheckFieldMessage(validator.In(code, mysql.AllPermissions...), validator.Path("permissions", i),
+			validator.Msg("must be a known permission").WithCode(validator.CodeInvalidChoice, "values", mysql.AllPermissions))
+	}
+
+	if v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	var path userPermissionPath
+
+	user, ok := app.readPathUser(w, r, &path)
+	if !ok {
+		return
+	}
+
+	if err := app.db.AddPermissionsForUser(r.Context(), user.ID, input.Permissions...); err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	app.ListUserPermissions(w, r)
+}
+
+func (app *App) RevokeUserPermission(w http.ResponseWriter, r *http.Request) {
+	var path userPermissionPath
+
+	user, ok := app.readPathUser(w, r, &path)
+	if !ok {
+		return
+	}
+
+	if err := app.db.RemovePermissionsForUser(r.Context(), user.ID, path.Permission); err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	app.ListUserPermissions(w, r)
+}
+
+// readPathUser decodes the route variables into path and returns the user
+// identified by the {id} variable. When it returns false a response has already
+// been written.
+func (app *App) readPathUser(w http.ResponseWriter, r *http.Request, path *userPermissionPath) (*mysql.User, bool) {
+	v := validator.New(i18n.FromRequest(r))
+	if err := request.DecodePath(r, path, &v); err != nil {
+		server.Error(w, r, err)
+		return nil, false
+	}
+
+	if v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return nil, false
+	}
+
+	user, err := app.db.GetUser(r.Context(), path.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, mysql.ErrRecordNotFound):
+			server.NotFound(w, r)
+		default:
+			server.Error(w, r, err)
+		}
+		return nil, false
+	}
+
+	return user, true
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/grocky/go-api-starter/cmd/api/request"
+	"github.com/grocky/go-api-starter/cmd/api/response"
+	"github.com/grocky/go-api-starter/cmd/api/server"
+)
+
+// CreateSession logs a browser client in with its login credentials.
+// The session token is set in a cookie and the CSRF token, which must be sent
+// back with state changing requests, is returned in the body.
+func (app *App) CreateSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var input loginInput
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	user, ok := app.login(w, r, input)
+	if !ok {
+		return
+	}
+
+	sess, err := app.sessions.Renew(ctx, w, r, user.ID)
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	data := map[string]any{
+		"user":       user,
+		"csrf_token": sess.CSRFToken,
+		"expiry":     sess.ExpiresAt,
+	}
+
+	if err := response.JSON(w, http.StatusCreated, data); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+// GetCurrentSession returns the user and CSRF token of the session cookie, so
+// that a reloaded browser app can recover its CSRF token.
+func (app *App) GetCurrentSession(w http.ResponseWriter, r *http.Request) {
+	sess := contextGetSession(r)
+	if sess == nil {
+		server.NotFound(w, r)
+		return
+	}
+
+	data := map[string]any{
+		"user":       contextGetUser(r),
+		"csrf_token": sess.CSRFToken,
+		"expiry":     sess.ExpiresAt,
+	}
+
+	if err := response.JSON(w, http.StatusOK, data); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+// DeleteCurrentSession logs the browser client out.
+func (app *App) DeleteCurrentSession(w http.ResponseWriter, r *http.Request) {
+	if err := app.sessions.Destroy(r.Context(), w, r); err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
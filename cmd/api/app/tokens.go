@@ -0,0 +1,267 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/grocky/go-api-starter/cmd/api/request"
+	"github.com/grocky/go-api-starter/cmd/api/response"
+	"github.com/grocky/go-api-starter/cmd/api/server"
+	"github.com/grocky/go-api-starter/internal/i18n"
+	"github.com/grocky/go-api-starter/internal/log"
+	"github.com/grocky/go-api-starter/internal/mysql"
+	"github.com/grocky/go-api-starter/internal/password"
+	"github.com/grocky/go-api-starter/internal/validator"
+)
+
+const (
+	passwordResetTTL  = 45 * time.Minute
+	authenticationTTL = 24 * time.Hour
+)
+
+// CreateAuthenticationToken exchanges login credentials for a bearer token.
+// Password hashes produced with outdated parameters are upgraded once the
+// password has been verified.
+func (app *App) CreateAuthenticationToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var input loginInput
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	user, ok := app.login(w, r, input)
+	if !ok {
+		return
+	}
+
+	token, err := app.db.NewToken(ctx, user.ID, authenticationTTL, mysql.ScopeAuthentication)
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	if err := response.JSON(w, http.StatusCreated, map[string]any{"authentication_token": token}); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+var errInvalidCredentials = errors.New("invalid credentials")
+
+// loginInput holds the credentials accepted by the login endpoints. Users first
+// send their email address and password. Users with two-factor authentication
+// enabled are then given a two-factor token, which they send back along with a
+// TOTP or recovery code.
+type loginInput struct {
+	Email          string `json:"email" validate:"omitempty,email,max=255"`
+	Password       string `json:"password"`
+	TwoFactorToken string `json:"two_factor_token" validate:"omitempty,min=26,max=26"`
+	Code           string `json:"code"`
+}
+
+// login returns the user authenticated by input. When it returns false a
+// response has already been written: either an error, or the pending
+// two-factor state for users who still have to supply a code. A wrong code
+// invalidates the two-factor token, so that codes cannot be guessed without
+// the password.
+func (app *App) login(w http.ResponseWriter, r *http.Request, input loginInput) (*mysql.User, bool) {
+	ctx := r.Context()
+
+	v := validator.New(i18n.FromRequest(r))
+	v.CheckStruct(input)
+
+	required := validator.Msg("must be provided").WithCode(validator.CodeRequired)
+	if input.TwoFactorToken == "" {
+		v.CheckFieldMessage(input.Email != "", "email", required)
+		v.CheckFieldMessage(input.Password != "", "password", required)
+	} else {
+		v.CheckFieldMessage(input.Code != "", "code", required)
+	}
+
+	if v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return nil, false
+	}
+
+	if input.TwoFactorToken != "" {
+		user, err := app.db.GetUserForToken(ctx, mysql.ScopeTwoFactor, input.TwoFactorToken)
+		if err != nil {
+			switch {
+			case errors.Is(err, mysql.ErrRecordNotFound):
+				v.AddFieldErrorf("two_factor_token", "invalid or expired two-factor token")
+				server.FailedValidation(w, r, v)
+			default:
+				server.Error(w, r, err)
+			}
+			return nil, false
+		}
+
+		ok, err := app.verifySecondFactor(ctx, user, input.Code)
+		if err != nil {
+			server.Error(w, r, err)
+			return nil, false
+		}
+
+		if err := app.db.DeleteAllTokensForUser(ctx, mysql.ScopeTwoFactor, user.ID); err != nil {
+			server.Error(w, r, err)
+			return nil, false
+		}
+
+		if !ok {
+			server.InvalidCredentials(w, r)
+			return nil, false
+		}
+
+		return user, true
+	}
+
+	user, err := app.authenticateUser(ctx, input.Email, input.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidCredentials):
+			server.InvalidCredentials(w, r)
+		default:
+			server.Error(w, r, err)
+		}
+		return nil, false
+	}
+
+	if !user.TOTPEnabled {
+		return user, true
+	}
+
+	token, err := app.db.NewToken(ctx, user.ID, twoFactorTTL, mysql.ScopeTwoFactor)
+	if err != nil {
+		server.Error(w, r, err)
+		return nil, false
+	}
+
+	data := map[string]any{
+		"two_factor_required": true,
+		"two_factor_token":    token,
+	}
+
+	if err := response.JSON(w, http.StatusAccepted, data); err != nil {
+		server.Error(w, r, err)
+	}
+
+	return nil, false
+}
+
+// dummyPasswordHash is compared with the password of unknown email addresses,
+// so that they take as long to reject as a wrong password.
+var dummyPasswordHash = sync.OnceValues(func() (string, error) {
+	return password.Hash("not the password of any user")
+})
+
+// authenticateUser returns the user with the email address and password, or
+// errInvalidCredentials. The stored hash is replaced when it needs a rehash.
+func (app *App) authenticateUser(ctx context.Context, email, plaintextPassword string) (*mysql.User, error) {
+	user, err := app.db.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, mysql.ErrRecordNotFound) {
+			if hash, err := dummyPasswordHash(); err == nil {
+				_, _ = password.Matches(plaintextPassword, hash)
+			}
+			return nil, errInvalidCredentials
+		}
+		return nil, err
+	}
+
+	match, err := password.Matches(plaintextPassword, user.PasswordHash)
+	if err != nil {
+		return nil, err
+	}
+	if !match {
+		return nil, errInvalidCredentials
+	}
+
+	if password.NeedsRehash(user.PasswordHash) {
+		hash, err := password.Hash(plaintextPassword)
+		if err != nil {
+			return nil, err
+		}
+		if err := app.db.RehashPassword(ctx, user.ID, user.PasswordHash, hash); err != nil {
+			return nil, err
+		}
+		user.PasswordHash = hash
+	}
+
+	return user, nil
+}
+
+// CreatePasswordResetToken emails a password reset token to the user. The
+// response is the same whether or not an activated user has the email
+// address, and the lookup happens in the background, so that the endpoint
+// cannot be used to discover which email addresses are registered.
+func (app *App) CreatePasswordResetToken(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email" validate:"required,email,max=255"`
+	}
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	v := validator.New(i18n.FromRequest(r))
+	if v.CheckStruct(input); v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	ctx := context.WithoutCancel(r.Context())
+
+	app.background(ctx, func() {
+		if err := app.sendPasswordResetToken(ctx, input.Email); err != nil {
+			log.FromContext(ctx).Error("unable to send password reset token", "error", err)
+		}
+	})
+
+	message := i18n.FromRequest(r).Sprintf("an email will be sent to you containing password reset instructions if an activated account exists for this email address")
+	if err := response.JSON(w, http.StatusAccepted, map[string]any{"message": message}); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+func (app *App) sendPasswordResetToken(ctx context.Context, email string) error {
+	user, err := app.db.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, mysql.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if !user.Activated {
+		return nil
+	}
+
+	err = app.db.WithTx(ctx, nil, func(tx *mysql.Tx) error {
+		token, err := tx.NewToken(ctx, user.ID, passwordResetTTL, mysql.ScopePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		data := map[string]any{
+			"Name":               user.FirstName,
+			"PasswordResetToken": token.Plaintext,
+			"PasswordResetURL":   app.cfg.BaseURL + "/password-reset?" + url.Values{"token": {token.Plaintext}}.Encode(),
+			"PasswordResetTTL":   passwordResetTTL,
+		}
+
+		return app.enqueueEmail(ctx, tx, user.Email, data, "token_password_reset.tmpl")
+	})
+	if err != nil {
+		return err
+	}
+
+	app.outbox.Notify()
+	return nil
+}
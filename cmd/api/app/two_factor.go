@@ -0,0 +1,220 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/grocky/go-api-starter/cmd/api/request"
+	"github.com/grocky/go-api-starter/cmd/api/response"
+	"github.com/grocky/go-api-starter/cmd/api/server"
+	"github.com/grocky/go-api-starter/internal/i18n"
+	"github.com/grocky/go-api-starter/internal/mysql"
+	"github.com/grocky/go-api-starter/internal/totp"
+	"github.com/grocky/go-api-starter/internal/validator"
+)
+
+// twoFactorTTL is how long a user has to supply a code after their password.
+const twoFactorTTL = 5 * time.Minute
+
+// EnrollTwoFactor generates a new TOTP secret for the authenticated user and
+// returns it along with its otpauth:// URI and a QR code of the URI. The
+// secret is only used once confirmed with ConfirmTwoFactor.
+func (app *App) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user := contextGetUser(r)
+
+	if user.TOTPEnabled {
+		v := validator.New(i18n.FromRequest(r))
+		v.AddErrorf("two-factor authentication is already enabled")
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	uri := totp.URI(app.cfg.JWTIssuer, user.Email, secret)
+
+	png, err := totp.QRCode(uri)
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	if err := app.db.SetTOTPSecret(r.Context(), user.ID, secret); err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	data := map[string]any{
+		"secret":  secret,
+		"uri":     uri,
+		"qr_code": "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}
+
+	if err := response.JSON(w, http.StatusCreated, data); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+// ConfirmTwoFactor enables two-factor authentication once the user supplies a
+// code generated from the enrolled secret, and returns their recovery codes.
+// The recovery codes are never shown again.
+func (app *App) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := contextGetUser(r)
+
+	var input struct {
+		Code string `json:"code" validate:"required"`
+	}
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	v := validator.New(i18n.FromRequest(r))
+	if v.CheckStruct(input); v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	if user.TOTPEnabled || !user.TOTPSecret.Valid {
+		v.AddErrorf("two-factor authentication must be enrolled and not yet enabled")
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	step, ok, err := totp.Validate(user.TOTPSecret.String, input.Code, time.Now(), user.TOTPLastStep)
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+	if !ok {
+		v.AddFieldErrorf("code", "invalid two-factor code")
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	var codes []string
+
+	err = app.db.WithTx(ctx, nil, func(tx *mysql.Tx) error {
+		if err := tx.EnableTOTP(ctx, user.ID, user.TOTPSecret.String, step); err != nil {
+			return err
+		}
+
+		codes, err = tx.ReplaceRecoveryCodes(ctx, user.ID)
+		return err
+	})
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	user.TOTPEnabled = true
+	user.TOTPLastStep = step
+
+	if err := response.JSON(w, http.StatusOK, map[string]any{"user": user, "recovery_codes": codes}); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+// DisableTwoFactor turns two-factor authentication off, given a current code
+// or a recovery code.
+func (app *App) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := contextGetUser(r)
+
+	var input struct {
+		Code string `json:"code" validate:"required"`
+	}
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	v := validator.New(i18n.FromRequest(r))
+	if v.CheckStruct(input); v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	if !user.TOTPEnabled {
+		v.AddErrorf("two-factor authentication is not enabled")
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	ok, err := app.verifySecondFactor(ctx, user, input.Code)
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+	if !ok {
+		v.AddFieldErrorf("code", "invalid two-factor code")
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	err = app.db.WithTx(ctx, nil, func(tx *mysql.Tx) error {
+		if err := tx.DisableTOTP(ctx, user.ID); err != nil {
+			return err
+		}
+
+		return tx.DeleteRecoveryCodes(ctx, user.ID)
+	})
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	user.TOTPEnabled = false
+	user.TOTPSecret = sql.NullString{}
+	user.TOTPLastStep = 0
+
+	if err := response.JSON(w, http.StatusOK, map[string]any{"user": user}); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+// verifySecondFactor reports whether code is a valid TOTP code or an unused
+// recovery code of the user. Accepted codes cannot be used again, even by
+// concurrent requests: the step or recovery code is only accepted by the
+// request whose update consumes it.
+func (app *App) verifySecondFactor(ctx context.Context, user *mysql.User, code string) (bool, error) {
+	if !user.TOTPSecret.Valid {
+		return false, nil
+	}
+
+	step, ok, err := totp.Validate(user.TOTPSecret.String, code, time.Now(), user.TOTPLastStep)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		if err := app.db.UseTOTPStep(ctx, user.ID, step); err != nil {
+			if errors.Is(err, mysql.ErrConflict) {
+				return false, nil
+			}
+			return false, err
+		}
+
+		user.TOTPLastStep = step
+		return true, nil
+	}
+
+	if err := app.db.UseRecoveryCode(ctx, user.ID, code); err != nil {
+		if errors.Is(err, mysql.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,237 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/grocky/go-api-starter/cmd/api/request"
+	"github.com/grocky/go-api-starter/cmd/api/response"
+	"github.com/grocky/go-api-starter/cmd/api/server"
+	"github.com/grocky/go-api-starter/internal/i18n"
+	"github.com/grocky/go-api-starter/internal/mysql"
+	"github.com/grocky/go-api-starter/internal/password"
+	"github.com/grocky/go-api-starter/internal/validator"
+)
+
+const activationTTL = 3 * 24 * time.Hour
+
+func (app *App) CreateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var input struct {
+		FirstName string `json:"first_name" validate:"required,max=500"`
+		LastName  string `json:"last_name" validate:"required,max=500"`
+		Email     string `json:"email" validate:"required,email,max=255"`
+		Password  string `json:"password"`
+	}
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	v := validator.New(i18n.FromRequest(r))
+	v.CheckStruct(input)
+	if err := app.cfg.PasswordPolicy.Validate(&v, "password", input.Password, input.Email, input.FirstName, input.LastName); err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	if v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	hash, err := password.Hash(input.Password)
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	user := &mysql.User{
+		ID:           uuid.NewString(),
+		Email:        input.Email,
+		PasswordHash: hash,
+		FirstName:    input.FirstName,
+		LastName:     input.LastName,
+		Activated:    false,
+	}
+
+	err = app.db.WithTx(ctx, nil, func(tx *mysql.Tx) error {
+		if err := tx.InsertUser(ctx, user); err != nil {
+			return err
+		}
+
+		if err := tx.AddPermissionsForUser(ctx, user.ID, mysql.DefaultPermissions...); err != nil {
+			return err
+		}
+
+		token, err := tx.NewToken(ctx, user.ID, activationTTL, mysql.ScopeActivation)
+		if err != nil {
+			return err
+		}
+
+		data := map[string]any{
+			"Name":            user.FirstName,
+			"UserID":          user.ID,
+			"ActivationToken": token.Plaintext,
+			"ActivationTTL":   activationTTL,
+		}
+
+		return app.enqueueEmail(ctx, tx, user.Email, data, "user_welcome.tmpl")
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, mysql.ErrDuplicateEmail):
+			v.AddFieldMessage("email", validator.Msg("a user with this email address already exists").WithCode(validator.CodeAlreadyExists))
+			server.FailedValidation(w, r, v)
+		default:
+			server.Error(w, r, err)
+		}
+		return
+	}
+
+	app.outbox.Notify()
+
+	if err := response.JSON(w, http.StatusAccepted, map[string]any{"user": user}); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+func (app *App) ActivateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var input struct {
+		Token string `json:"token" validate:"required,min=26,max=26"`
+	}
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	v := validator.New(i18n.FromRequest(r))
+	if v.CheckStruct(input); v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	user, err := app.db.GetUserForToken(ctx, mysql.ScopeActivation, input.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, mysql.ErrRecordNotFound):
+			v.AddFieldErrorf("token", "invalid or expired activation token")
+			server.FailedValidation(w, r, v)
+		default:
+			server.Error(w, r, err)
+		}
+		return
+	}
+
+	err = app.db.WithTx(ctx, nil, func(tx *mysql.Tx) error {
+		if err := tx.SetActivated(ctx, user.ID); err != nil {
+			return err
+		}
+
+		return tx.DeleteAllTokensForUser(ctx, mysql.ScopeActivation, user.ID)
+	})
+	if err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	user.Activated = true
+
+	if err := response.JSON(w, http.StatusOK, map[string]any{"user": user}); err != nil {
+		server.Error(w, r, err)
+	}
+}
+
+// UpdateUserPassword sets a new password using a password reset token. The
+// token is consumed in the same transaction as the password is set, then every
+// other token of the user is deleted, including other reset tokens.
+func (app *App) UpdateUserPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var input struct {
+		Password string `json:"password"`
+		Token    string `json:"token" validate:"required,min=26,max=26"`
+	}
+
+	if err := request.DecodeJSON(w, r, &input); err != nil {
+		server.BadRequest(w, r, err)
+		return
+	}
+
+	v := validator.New(i18n.FromRequest(r))
+	if v.CheckStruct(input); v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	user, err := app.db.GetUserForToken(ctx, mysql.ScopePasswordReset, input.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, mysql.ErrRecordNotFound):
+			v.AddFieldErrorf("token", "invalid or expired password reset token")
+			server.FailedValidation(w, r, v)
+		default:
+			server.Error(w, r, err)
+		}
+		return
+	}
+
+	if err := app.cfg.PasswordPolicy.Validate(&v, "password", input.Password, user.Email, user.FirstName, user.LastName); err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	if v.HasErrors() {
+		server.FailedValidation(w, r, v)
+		return
+	}
+
+	if user.PasswordHash, err = password.Hash(input.Password); err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	err = app.db.WithTx(ctx, nil, func(tx *mysql.Tx) error {
+		if err := tx.ConsumeToken(ctx, mysql.ScopePasswordReset, input.Token); err != nil {
+			return err
+		}
+
+		if err := tx.SetPasswordHash(ctx, user.ID, user.PasswordHash); err != nil {
+			return err
+		}
+
+		if err := tx.DeleteAllUserTokens(ctx, user.ID); err != nil {
+			return err
+		}
+
+		return tx.DeleteAllRefreshTokensForUser(ctx, user.ID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, mysql.ErrRecordNotFound):
+			v.AddFieldErrorf("token", "invalid or expired password reset token")
+			server.FailedValidation(w, r, v)
+		default:
+			server.Error(w, r, err)
+		}
+		return
+	}
+
+	if err := app.sessions.Store.DeleteAllForUser(ctx, user.ID); err != nil {
+		server.Error(w, r, err)
+		return
+	}
+
+	message := i18n.FromRequest(r).Sprintf("your password was successfully reset")
+	if err := response.JSON(w, http.StatusOK, map[string]any{"message": message}); err != nil {
+		server.Error(w, r, err)
+	}
+}
@@ -3,16 +3,22 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"os/signal"
 	"runtime/debug"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/grocky/go-api-starter/cmd/api/app"
 	"github.com/grocky/go-api-starter/cmd/api/server"
+	"github.com/grocky/go-api-starter/internal/jwt"
 	"github.com/grocky/go-api-starter/internal/log"
 	"github.com/grocky/go-api-starter/internal/mysql"
+	"github.com/grocky/go-api-starter/internal/password"
+	"github.com/grocky/go-api-starter/internal/smtp"
 )
 
 const appName = "go-api-starter"
@@ -49,12 +55,39 @@ func main() {
 type config struct {
 	httpPort int
 	db       struct {
+		host         string
+		port         int
+		user         string
+		password     string
+		tls          string
+		collation    string
+		timeout      time.Duration
+		readTimeout  time.Duration
+		writeTimeout time.Duration
+		params       map[string]string
+		replicas     []string
+		balancer     mysql.Balancer
+		logQueries   bool
+		slowQuery    time.Duration
+		queryTimeout time.Duration
+	}
+	smtp struct {
 		host     string
 		port     int
-		user     string
+		username string
 		password string
+		sender   string
+	}
+	passwordBreachesPath string
+	jwt                  struct {
+		keys         string
+		signingKeyID string
+		issuer       string
 	}
-	version bool
+	baseURL        string
+	secureCookies  bool
+	jobConcurrency int
+	version        bool
 }
 
 func run(ctx context.Context) error {
@@ -77,11 +110,118 @@ func run(ctx context.Context) error {
 	}
 	cfg.db.user = os.Getenv("DB_USER")
 	cfg.db.password = os.Getenv("DB_PASS")
+	cfg.db.tls = os.Getenv("DB_TLS")
+	cfg.db.collation = os.Getenv("DB_COLLATION")
+	for name, d := range map[string]*time.Duration{
+		"DB_TIMEOUT":              &cfg.db.timeout,
+		"DB_READ_TIMEOUT":         &cfg.db.readTimeout,
+		"DB_WRITE_TIMEOUT":        &cfg.db.writeTimeout,
+		"DB_SLOW_QUERY_THRESHOLD": &cfg.db.slowQuery,
+		"DB_QUERY_TIMEOUT":        &cfg.db.queryTimeout,
+	} {
+		if os.Getenv(name) != "" {
+			if *d, err = time.ParseDuration(os.Getenv(name)); err != nil {
+				return fmt.Errorf("%s must be a duration, %w", name, err)
+			}
+		}
+	}
+	if cfg.db.params, err = mysql.ParseParams(os.Getenv("DB_PARAMS")); err != nil {
+		return fmt.Errorf("DB_PARAMS: %w", err)
+	}
+	for _, addr := range strings.Split(os.Getenv("DB_REPLICAS"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			cfg.db.replicas = append(cfg.db.replicas, addr)
+		}
+	}
+	if os.Getenv("DB_LOG_QUERIES") != "" {
+		if cfg.db.logQueries, err = strconv.ParseBool(os.Getenv("DB_LOG_QUERIES")); err != nil {
+			return fmt.Errorf("DB_LOG_QUERIES must be a boolean, %w", err)
+		}
+	}
+	cfg.db.balancer = mysql.RoundRobin
+	if os.Getenv("DB_REPLICA_BALANCER") != "" {
+		if cfg.db.balancer, err = mysql.ParseBalancer(os.Getenv("DB_REPLICA_BALANCER")); err != nil {
+			return fmt.Errorf("DB_REPLICA_BALANCER: %w", err)
+		}
+	}
+
+	cfg.smtp.host = os.Getenv("SMTP_HOST")
+	cfg.smtp.port = 25
+	if os.Getenv("SMTP_PORT") != "" {
+		if cfg.smtp.port, err = strconv.Atoi(os.Getenv("SMTP_PORT")); err != nil {
+			return fmt.Errorf("SMTP_PORT must be an integer, %w", err)
+		}
+	}
+	cfg.smtp.username = os.Getenv("SMTP_USER")
+	cfg.smtp.password = os.Getenv("SMTP_PASS")
+	cfg.smtp.sender = os.Getenv("SMTP_SENDER")
+	if cfg.smtp.sender == "" {
+		cfg.smtp.sender = "go-api-starter <no-reply@go-api-starter.local>"
+	}
+
+	cfg.passwordBreachesPath = os.Getenv("PASSWORD_BREACHES_PATH")
+
+	cfg.baseURL = fmt.Sprintf("http://localhost:%d", cfg.httpPort)
+	if os.Getenv("APP_BASE_URL") != "" {
+		u, err := url.Parse(os.Getenv("APP_BASE_URL"))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("APP_BASE_URL must be an absolute URL, got %q", os.Getenv("APP_BASE_URL"))
+		}
+		cfg.baseURL = strings.TrimSuffix(u.String(), "/")
+	}
+
+	cfg.secureCookies = true
+	if os.Getenv("SECURE_COOKIES") != "" {
+		if cfg.secureCookies, err = strconv.ParseBool(os.Getenv("SECURE_COOKIES")); err != nil {
+			return fmt.Errorf("SECURE_COOKIES must be a boolean, %w", err)
+		}
+	}
+
+	if os.Getenv("JOBS_CONCURRENCY") != "" {
+		if cfg.jobConcurrency, err = strconv.Atoi(os.Getenv("JOBS_CONCURRENCY")); err != nil {
+			return fmt.Errorf("JOBS_CONCURRENCY must be an integer, %w", err)
+		}
+		if cfg.jobConcurrency <= 0 {
+			return fmt.Errorf("JOBS_CONCURRENCY must be positive, got %d", cfg.jobConcurrency)
+		}
+	}
+
+	cfg.jwt.keys = os.Getenv("JWT_KEYS")
+	cfg.jwt.signingKeyID = os.Getenv("JWT_SIGNING_KEY_ID")
+	cfg.jwt.issuer = os.Getenv("JWT_ISSUER")
+	if cfg.jwt.issuer == "" {
+		cfg.jwt.issuer = appName
+	}
 
 	var db *mysql.DB
 	dbConfig := mysql.NewConfig(appName, cfg.db.host, cfg.db.port, cfg.db.user, cfg.db.password)
+	dbConfig.TLSConfig = cfg.db.tls
+	if cfg.db.collation != "" {
+		dbConfig.Collation = cfg.db.collation
+	}
+	if cfg.db.timeout > 0 {
+		dbConfig.Timeout = cfg.db.timeout
+	}
+	if cfg.db.readTimeout > 0 {
+		dbConfig.ReadTimeout = cfg.db.readTimeout
+	}
+	if cfg.db.writeTimeout > 0 {
+		dbConfig.WriteTimeout = cfg.db.writeTimeout
+	}
+	if len(cfg.db.params) > 0 {
+		dbConfig.Params = cfg.db.params
+	}
+	dbConfig.Replicas = cfg.db.replicas
+	dbConfig.ReplicaBalancer = cfg.db.balancer
+	dbConfig.LogQueries = cfg.db.logQueries
+	if cfg.db.queryTimeout > 0 {
+		dbConfig.QueryTimeout = cfg.db.queryTimeout
+	}
+	if cfg.db.slowQuery > 0 {
+		dbConfig.SlowQueryThreshold = cfg.db.slowQuery
+	}
 	if db, err = mysql.New(ctx, dbConfig); err != nil {
-		logger.Error("unable to connect to mysql", "host", cfg.db.host, "port", cfg.db.port, "error", err)
+		logger.Error("unable to connect to mysql", "dsn", dbConfig.Redacted(), "error", err)
 		return fmt.Errorf("unable to connect to mysql")
 	}
 	defer func(db *mysql.DB) {
@@ -90,14 +230,81 @@ func run(ctx context.Context) error {
 		}
 	}(db)
 
-	app := app.New(db)
+	if args := os.Args[1:]; len(args) > 0 && args[0] == "migrate" {
+		return migrate(ctx, db, args[1:])
+	}
+
+	mailer := smtp.NewMailer(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender)
+
+	passwordPolicy := password.DefaultPolicy()
+	if cfg.passwordBreachesPath != "" {
+		breaches, err := password.OpenBreached(cfg.passwordBreachesPath)
+		if err != nil {
+			return fmt.Errorf("password.OpenBreached: %w", err)
+		}
+		defer func() {
+			if err := breaches.Close(); err != nil {
+				logger.Error("error while closing the password breaches", "error", err)
+			}
+		}()
+
+		passwordPolicy.Breaches = breaches
+	}
+
+	keySet, err := newKeySet(logger, cfg.jwt.keys, cfg.jwt.signingKeyID)
+	if err != nil {
+		return err
+	}
+
+	app := app.New(db, mailer, app.Config{
+		PasswordPolicy: passwordPolicy,
+		JWT:            keySet,
+		JWTIssuer:      cfg.jwt.issuer,
+		BaseURL:        cfg.baseURL,
+		SecureCookies:  cfg.secureCookies,
+		JobConcurrency: cfg.jobConcurrency,
+	})
 
 	srv, err := server.New(cfg.httpPort)
 	if err != nil {
 		return fmt.Errorf("server.New: %w", err)
 	}
 
+	app.RunOutbox(ctx)
+	app.RunJobs(ctx)
+
 	logger.Info("server listening", "port", cfg.httpPort)
 
-	return srv.ServeHTTPHandler(ctx, app.Routes(ctx))
+	err = srv.ServeHTTPHandler(ctx, app.Routes(ctx))
+
+	logger.Warn("waiting for background tasks to complete")
+	app.Wait()
+
+	return err
+}
+
+// newKeySet builds the JWT key set from the JWT_KEYS specification. Without
+// keys, an ephemeral Ed25519 key is generated, so access tokens do not survive
+// a restart and are not shared between instances.
+func newKeySet(logger *log.Logger, spec, signingKeyID string) (*jwt.KeySet, error) {
+	keys, err := jwt.ParseKeys(spec)
+	if err != nil {
+		return nil, fmt.Errorf("JWT_KEYS: %w", err)
+	}
+
+	if len(keys) == 0 {
+		logger.Warn("JWT_KEYS is not set, signing access tokens with an ephemeral key")
+
+		key, err := jwt.GenerateEdDSAKey("ephemeral")
+		if err != nil {
+			return nil, fmt.Errorf("jwt.GenerateEdDSAKey: %w", err)
+		}
+		return jwt.NewKeySet(key.ID, key)
+	}
+
+	if signingKeyID == "" {
+		signingKeyID = keys[0].ID
+	}
+
+	return jwt.NewKeySet(signingKeyID, keys...)
 }
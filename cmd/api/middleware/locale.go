@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/grocky/go-api-starter/internal/i18n"
+)
+
+// Localize negotiates the response language from the Accept-Language header
+// and stores a printer for it in the request context.
+func Localize() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tag := i18n.Negotiate(r.Header.Get("Accept-Language"))
+
+			w.Header().Add("Vary", "Accept-Language")
+			w.Header().Set("Content-Language", tag.String())
+
+			ctx := i18n.WithPrinter(r.Context(), i18n.NewPrinter(tag))
+			r = r.Clone(ctx)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -11,11 +11,12 @@ func PopulateLogger(logger *log.Logger) mux.MiddlewareFunc {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
 
+			requestLogger := logger
 			if id := RequestIDFromContext(ctx); id != "" {
-				logger = logger.With("requestId", id)
+				requestLogger = logger.With("requestId", id)
 			}
 
-			ctx = log.WithLogger(ctx, logger)
+			ctx = log.WithLogger(ctx, requestLogger)
 			r = r.Clone(ctx)
 
 			next.ServeHTTP(w, r)
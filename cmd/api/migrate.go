@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/grocky/go-api-starter/assets"
+	"github.com/grocky/go-api-starter/internal/mysql"
+)
+
+const migrateUsage = "usage: api migrate up|down|status|to VERSION"
+
+// migrate runs the migrate subcommand:
+//
+//	api migrate up          apply every pending migration
+//	api migrate down        roll back the last applied migration
+//	api migrate status      list migrations and when they were applied
+//	api migrate to VERSION  apply or roll back until VERSION is the last applied
+func migrate(ctx context.Context, db *mysql.DB, args []string) error {
+	migrations, err := fs.Sub(assets.EmbeddedFiles, "migrations")
+	if err != nil {
+		return err
+	}
+
+	migrator, err := db.Migrator(migrations)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx)
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("VERSION must be an integer, %w", err)
+		}
+		return migrator.To(ctx, version)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printMigrationStatus(statuses)
+	}
+
+	return errors.New(migrateUsage)
+}
+
+func printMigrationStatus(statuses []mysql.MigrationStatus) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT\t")
+
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Modified {
+			appliedAt += " (modified)"
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t\n", s.Version, s.Name, appliedAt)
+	}
+
+	return w.Flush()
+}
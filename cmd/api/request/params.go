@@ -0,0 +1,247 @@
+package request
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/grocky/go-api-starter/internal/validator"
+)
+
+// DecodeQuery binds the URL query string of r into the struct pointed to by
+// dst. Fields are matched by their `query` struct tag.
+//
+// Keys which are missing from the query string leave the field untouched, so
+// defaults can be set on dst before decoding. Values which cannot be converted
+// are reported as field errors on v rather than returned, which allows the
+// caller to respond with server.FailedValidation.
+//
+// The following field types are supported: string, bool, signed and unsigned
+// integers, floats, time.Time, time.Duration, encoding.TextUnmarshaler,
+// pointers to any of these and slices of any of these. Slice values may be given
+// as a comma separated list (?ids=1,2,3), as repeated keys (?ids=1&ids=2) or a
+// combination of both.
+//
+// Additional struct tags control the conversion:
+//
+//	enum:"asc,desc"      the value must be one of the listed values
+//	layout:"2006-01-02"  the time.Time layout, defaults to time.RFC3339
+func DecodeQuery(r *http.Request, dst any, v *validator.Validator) error {
+	return decodeValues(r.URL.Query(), "query", dst, v)
+}
+
+// DecodePath binds the gorilla/mux route variables of r into the struct
+// pointed to by dst. Fields are matched by their `path` struct tag and
+// support the same types and tags as DecodeQuery.
+func DecodePath(r *http.Request, dst any, v *validator.Validator) error {
+	values := url.Values{}
+	for key, value := range mux.Vars(r) {
+		values.Set(key, value)
+	}
+
+	return decodeValues(values, "path", dst, v)
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// decodeValues binds values into dst using the struct tag named tagName.
+func decodeValues(values url.Values, tagName string, dst any, v *validator.Validator) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("request: destination must be a non-nil pointer to a struct, got %T", dst)
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key := strings.Split(field.Tag.Get(tagName), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+
+		raw, ok := values[key]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+
+		err := setField(rv.Field(i), field, raw)
+		if err != nil {
+			var convErr *conversionError
+			if errors.As(err, &convErr) {
+				v.AddFieldMessage(key, validator.Msg(convErr.format, convErr.args...).WithCode(validator.CodeInvalidType))
+				continue
+			}
+			return err
+		}
+
+		if enum := field.Tag.Get("enum"); enum != "" {
+			safelist := strings.Split(enum, ",")
+
+			given := raw[len(raw)-1:]
+			if field.Type.Kind() == reflect.Slice {
+				given = splitList(raw)
+			}
+
+			if !validator.AllIn(given, safelist...) {
+				msg := validator.Msg("must be one of %s", strings.Join(safelist, ", "))
+				v.AddFieldMessage(key, msg.WithCode(validator.CodeInvalidChoice, "values", safelist))
+			}
+		}
+	}
+
+	return nil
+}
+
+// conversionError reports a value which could not be converted into the
+// field's type. Its message is intended for the client and is translated by
+// the validator.
+type conversionError struct {
+	format string
+	args   []any
+}
+
+func (e *conversionError) Error() string {
+	return fmt.Sprintf(e.format, e.args...)
+}
+
+func setField(fv reflect.Value, field reflect.StructField, raw []string) error {
+	ft := field.Type
+
+	if ft.Kind() == reflect.Slice && !implementsTextUnmarshaler(ft) {
+		items := splitList(raw)
+		slice := reflect.MakeSlice(ft, 0, len(items))
+
+		for _, item := range items {
+			elem := reflect.New(ft.Elem()).Elem()
+			if err := setValue(elem, field, item); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+
+		fv.Set(slice)
+		return nil
+	}
+
+	return setValue(fv, field, raw[len(raw)-1])
+}
+
+func setValue(fv reflect.Value, field reflect.StructField, s string) error {
+	if fv.Kind() == reflect.Pointer {
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setValue(ptr.Elem(), field, s); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	}
+
+	// time.Time is an encoding.TextUnmarshaler too, so it is matched first for
+	// the layout tag to apply.
+	switch fv.Type() {
+	case timeType:
+		layout := field.Tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return &conversionError{format: "must be a valid time in the format %s", args: []any{layout}}
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return &conversionError{format: "must be a valid duration"}
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	if fv.CanAddr() && implementsTextUnmarshaler(fv.Type()) {
+		u := fv.Addr().Interface().(encoding.TextUnmarshaler)
+		if err := u.UnmarshalText([]byte(s)); err != nil {
+			return &conversionError{format: "must be a valid value"}
+		}
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return &conversionError{format: "must be a boolean value"}
+		}
+		fv.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return &conversionError{format: "must be an integer value"}
+		}
+		fv.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return &conversionError{format: "must be a positive integer value"}
+		}
+		fv.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return &conversionError{format: "must be a number"}
+		}
+		fv.SetFloat(f)
+
+	default:
+		return fmt.Errorf("request: unsupported type %s for field %s", fv.Type(), field.Name)
+	}
+
+	return nil
+}
+
+func implementsTextUnmarshaler(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// splitList flattens repeated and comma separated values into a single list,
+// dropping empty entries.
+func splitList(raw []string) []string {
+	var items []string
+
+	for _, value := range raw {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,277 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/grocky/go-api-starter/internal/validator"
+)
+
+type queryParams struct {
+	Page    int           `query:"page"`
+	Limit   uint          `query:"limit"`
+	Ratio   float64       `query:"ratio"`
+	Sort    string        `query:"sort" enum:"asc,desc"`
+	Fields  []string      `query:"fields" enum:"id,name,email"`
+	IDs     []int         `query:"ids"`
+	Active  *bool         `query:"active"`
+	Since   time.Time     `query:"since"`
+	Day     time.Time     `query:"day" layout:"2006-01-02"`
+	Timeout time.Duration `query:"timeout"`
+	Addr    netip.Addr    `query:"addr"`
+	Ignored string        `query:"-"`
+}
+
+func TestDecodeQuery(t *testing.T) {
+	active := true
+	defaults := queryParams{Page: 1, Limit: 20, Sort: "asc"}
+
+	tests := []struct {
+		name       string
+		query      string
+		want       queryParams
+		wantFields map[string]string
+	}{
+		{
+			name:  "defaults kept",
+			query: "",
+			want:  defaults,
+		},
+		{
+			name:  "every type",
+			query: "page=3&limit=50&ratio=0.5&sort=desc&fields=id,email&ids=1,2&ids=3&active=true&since=2024-05-01T10:00:00Z&day=2024-05-02&timeout=1m30s&addr=10.0.0.1&Ignored=x",
+			want: queryParams{
+				Page:    3,
+				Limit:   50,
+				Ratio:   0.5,
+				Sort:    "desc",
+				Fields:  []string{"id", "email"},
+				IDs:     []int{1, 2, 3},
+				Active:  &active,
+				Since:   time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+				Day:     time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+				Timeout: 90 * time.Second,
+				Addr:    netip.MustParseAddr("10.0.0.1"),
+			},
+		},
+		{
+			name:  "last repeated value wins",
+			query: "page=2&page=4",
+			want:  queryParams{Page: 4, Limit: 20, Sort: "asc"},
+		},
+		{
+			name:       "bad int",
+			query:      "page=two",
+			want:       defaults,
+			wantFields: map[string]string{"page": "must be an integer value"},
+		},
+		{
+			name:       "int out of range",
+			query:      "page=99999999999999999999",
+			want:       defaults,
+			wantFields: map[string]string{"page": "must be an integer value"},
+		},
+		{
+			name:       "negative uint",
+			query:      "limit=-1",
+			want:       defaults,
+			wantFields: map[string]string{"limit": "must be a positive integer value"},
+		},
+		{
+			name:       "bad float",
+			query:      "ratio=half",
+			want:       defaults,
+			wantFields: map[string]string{"ratio": "must be a number"},
+		},
+		{
+			name:       "bad bool",
+			query:      "active=maybe",
+			want:       defaults,
+			wantFields: map[string]string{"active": "must be a boolean value"},
+		},
+		{
+			name:       "bad time",
+			query:      "since=yesterday",
+			want:       defaults,
+			wantFields: map[string]string{"since": "must be a valid time in the format " + time.RFC3339},
+		},
+		{
+			name:       "time in another layout",
+			query:      "day=02/05/2024",
+			want:       defaults,
+			wantFields: map[string]string{"day": "must be a valid time in the format 2006-01-02"},
+		},
+		{
+			name:       "bad duration",
+			query:      "timeout=soon",
+			want:       defaults,
+			wantFields: map[string]string{"timeout": "must be a valid duration"},
+		},
+		{
+			name:       "bad text",
+			query:      "addr=localhost",
+			want:       defaults,
+			wantFields: map[string]string{"addr": "must be a valid value"},
+		},
+		{
+			name:       "bad slice item",
+			query:      "ids=1,x",
+			want:       defaults,
+			wantFields: map[string]string{"ids": "must be an integer value"},
+		},
+		{
+			name:       "enum violation",
+			query:      "sort=sideways",
+			want:       queryParams{Page: 1, Limit: 20, Sort: "sideways"},
+			wantFields: map[string]string{"sort": "must be one of asc, desc"},
+		},
+		{
+			name:       "slice enum violation",
+			query:      "fields=id,password",
+			want:       queryParams{Page: 1, Limit: 20, Sort: "asc", Fields: []string{"id", "password"}},
+			wantFields: map[string]string{"fields": "must be one of id, name, email"},
+		},
+		{
+			name:  "several errors",
+			query: "page=x&limit=y",
+			want:  defaults,
+			wantFields: map[string]string{
+				"page":  "must be an integer value",
+				"limit": "must be a positive integer value",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+
+			got := defaults
+			var v validator.Validator
+			if err := DecodeQuery(r, &got, &v); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeQuery() = %+v, want %+v", got, tt.want)
+			}
+			if len(v.FieldErrors) != len(tt.wantFields) {
+				t.Errorf("field errors = %v, want %v", v.FieldErrors, tt.wantFields)
+			}
+			for key, want := range tt.wantFields {
+				if v.FieldErrors[key] != want {
+					t.Errorf("field error %q = %q, want %q", key, v.FieldErrors[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeQueryUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		dst  any
+	}{
+		{"map", &struct {
+			M map[string]string `query:"m"`
+		}{}},
+		{"struct", &struct {
+			S struct{ A int } `query:"m"`
+		}{}},
+		{"channel", &struct {
+			C chan int `query:"m"`
+		}{}},
+		{"slice of maps", &struct {
+			S []map[string]int `query:"m"`
+		}{}},
+		{"not a pointer", struct {
+			M string `query:"m"`
+		}{}},
+		{"nil pointer", (*struct {
+			M string `query:"m"`
+		})(nil)},
+		{"pointer to a non struct", new(string)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/?m=1", nil)
+
+			var v validator.Validator
+			if err := DecodeQuery(r, tt.dst, &v); err == nil {
+				t.Error("DecodeQuery() error = nil, want an error")
+			}
+			if v.HasErrors() {
+				t.Errorf("DecodeQuery() added validation errors %v, want none", v.FieldErrors)
+			}
+		})
+	}
+}
+
+func TestDecodePath(t *testing.T) {
+	type pathParams struct {
+		ID   int    `path:"id"`
+		Slug string `path:"slug"`
+	}
+
+	tests := []struct {
+		name       string
+		vars       map[string]string
+		want       pathParams
+		wantFields map[string]string
+	}{
+		{
+			name: "every variable",
+			vars: map[string]string{"id": "42", "slug": "hello"},
+			want: pathParams{ID: 42, Slug: "hello"},
+		},
+		{
+			name: "missing variable",
+			vars: map[string]string{"id": "42"},
+			want: pathParams{ID: 42, Slug: "default"},
+		},
+		{
+			name: "no variables",
+			want: pathParams{ID: -1, Slug: "default"},
+		},
+		{
+			name:       "bad int",
+			vars:       map[string]string{"id": "abc", "slug": "hello"},
+			want:       pathParams{ID: -1, Slug: "hello"},
+			wantFields: map[string]string{"id": "must be an integer value"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.vars != nil {
+				r = mux.SetURLVars(r, tt.vars)
+			}
+
+			got := pathParams{ID: -1, Slug: "default"}
+			var v validator.Validator
+			if err := DecodePath(r, &got, &v); err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("DecodePath() = %+v, want %+v", got, tt.want)
+			}
+			if len(v.FieldErrors) != len(tt.wantFields) {
+				t.Errorf("field errors = %v, want %v", v.FieldErrors, tt.wantFields)
+			}
+			for key, want := range tt.wantFields {
+				if v.FieldErrors[key] != want {
+					t.Errorf("field error %q = %q, want %q", key, v.FieldErrors[key], want)
+				}
+			}
+		})
+	}
+}
@@ -1,16 +1,24 @@
 package server
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"github.com/grocky/go-api-starter/cmd/api/response"
+	"github.com/grocky/go-api-starter/internal/i18n"
 	"github.com/grocky/go-api-starter/internal/log"
+	"github.com/grocky/go-api-starter/internal/mysql"
 	"github.com/grocky/go-api-starter/internal/validator"
 	"net/http"
 	"runtime/debug"
+	"strings"
 )
 
 func ErrorMessage(w http.ResponseWriter, r *http.Request, status int, clientMessage string) {
-	err := response.JSON(w, status, map[string]string{"error": clientMessage})
+	ErrorMessageWithHeaders(w, r, status, clientMessage, nil)
+}
+
+func ErrorMessageWithHeaders(w http.ResponseWriter, r *http.Request, status int, clientMessage string, headers http.Header) {
+	err := response.JSONWithHeaders(w, status, map[string]string{"error": clientMessage}, headers)
 	if err != nil {
 		logger := log.FromContext(r.Context())
 		logger.Error("unable to marshal json response", "error", err, "clientMessage", clientMessage)
@@ -24,13 +32,55 @@ func ErrorMessageLog(w http.ResponseWriter, r *http.Request, status int, clientM
 	ErrorMessage(w, r, status, clientMessage)
 }
 
+// Error responds to an unexpected error. Database errors which are the
+// client's doing are answered with 404, 409 or 422, and timeouts with 504,
+// instead of 500. Nothing is sent when the client has gone away.
 func Error(w http.ResponseWriter, r *http.Request, err error) {
-	message := "The server encountered a problem and could not process your request"
-	ErrorMessageLog(w, r, http.StatusInternalServerError, message, err)
+	var dup *mysql.DuplicateError
+
+	switch {
+	case errors.Is(err, context.Canceled) && r.Context().Err() != nil:
+		log.FromContext(r.Context()).Warn("request canceled", "error", err)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, mysql.ErrTimeout):
+		log.FromContext(r.Context()).Warn("request timed out", "error", err)
+		Timeout(w, r)
+	case errors.Is(err, mysql.ErrNotFound):
+		NotFound(w, r)
+	case errors.As(err, &dup) && dup.Key != "":
+		// Unique indexes are named after their column, prefixed with uc_.
+		Duplicate(w, r, strings.TrimPrefix(dup.Key, "uc_"))
+	case errors.Is(err, mysql.ErrDuplicate), errors.Is(err, mysql.ErrConflict):
+		Conflict(w, r)
+	case errors.Is(err, mysql.ErrTooLong):
+		ValueTooLong(w, r)
+	default:
+		message := i18n.FromRequest(r).Sprintf("The server encountered a problem and could not process your request")
+		ErrorMessageLog(w, r, http.StatusInternalServerError, message, err)
+	}
+}
+
+func Conflict(w http.ResponseWriter, r *http.Request) {
+	message := i18n.FromRequest(r).Sprintf("The request conflicts with the current state of the resource, please try again")
+	ErrorMessage(w, r, http.StatusConflict, message)
+}
+
+func Duplicate(w http.ResponseWriter, r *http.Request, field string) {
+	message := i18n.FromRequest(r).Sprintf("A resource with the same %s already exists", field)
+	ErrorMessage(w, r, http.StatusConflict, message)
+}
+
+func ValueTooLong(w http.ResponseWriter, r *http.Request) {
+	message := i18n.FromRequest(r).Sprintf("A value in the request is too long")
+	ErrorMessage(w, r, http.StatusUnprocessableEntity, message)
+}
+
+func Timeout(w http.ResponseWriter, r *http.Request) {
+	message := i18n.FromRequest(r).Sprintf("The server timed out while processing your request, please try again")
+	ErrorMessage(w, r, http.StatusGatewayTimeout, message)
 }
 
 func NotFound(w http.ResponseWriter, r *http.Request) {
-	message := "The requested resource could not be found"
+	message := i18n.FromRequest(r).Sprintf("The requested resource could not be found")
 	ErrorMessage(w, r, http.StatusNotFound, message)
 	http.NotFoundHandler()
 }
@@ -38,7 +88,7 @@ func NotFound(w http.ResponseWriter, r *http.Request) {
 func NotFoundHandler() http.Handler { return http.HandlerFunc(NotFound) }
 
 func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
-	message := fmt.Sprintf("The %s method is not supported for this resource", r.Method)
+	message := i18n.FromRequest(r).Sprintf("The %s method is not supported for this resource", r.Method)
 	ErrorMessage(w, r, http.StatusMethodNotAllowed, message)
 }
 
@@ -59,17 +109,44 @@ func InvalidAuthenticationToken(w http.ResponseWriter, r *http.Request) {
 	headers := make(http.Header)
 	headers.Set("WWW-Authenticate", "Bearer")
 
-	ErrorMessage(w, r, http.StatusUnauthorized, "Invalid authentication token")
+	message := i18n.FromRequest(r).Sprintf("Invalid authentication token")
+	ErrorMessageWithHeaders(w, r, http.StatusUnauthorized, message, headers)
 }
 
 func AuthenticationRequired(w http.ResponseWriter, r *http.Request) {
-	ErrorMessage(w, r, http.StatusUnauthorized, "You must be authenticated to access this resource")
+	message := i18n.FromRequest(r).Sprintf("You must be authenticated to access this resource")
+	ErrorMessage(w, r, http.StatusUnauthorized, message)
+}
+
+func InvalidCredentials(w http.ResponseWriter, r *http.Request) {
+	message := i18n.FromRequest(r).Sprintf("Invalid authentication credentials")
+	ErrorMessage(w, r, http.StatusUnauthorized, message)
+}
+
+func InactiveAccount(w http.ResponseWriter, r *http.Request) {
+	message := i18n.FromRequest(r).Sprintf("Your user account must be activated to access this resource")
+	ErrorMessage(w, r, http.StatusForbidden, message)
+}
+
+func NotPermitted(w http.ResponseWriter, r *http.Request) {
+	message := i18n.FromRequest(r).Sprintf("Your user account doesn't have the necessary permissions to access this resource")
+	ErrorMessage(w, r, http.StatusForbidden, message)
+}
+
+func APIKeyNotAllowed(w http.ResponseWriter, r *http.Request) {
+	message := i18n.FromRequest(r).Sprintf("This resource cannot be accessed with an API key")
+	ErrorMessage(w, r, http.StatusForbidden, message)
+}
+
+func InvalidCSRFToken(w http.ResponseWriter, r *http.Request) {
+	message := i18n.FromRequest(r).Sprintf("Invalid or missing CSRF token")
+	ErrorMessage(w, r, http.StatusForbidden, message)
 }
 
 func BasicAuthenticationRequired(w http.ResponseWriter, r *http.Request) {
 	headers := make(http.Header)
 	headers.Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
 
-	message := "You must be authenticated to access this resource"
-	ErrorMessage(w, r, http.StatusUnauthorized, message)
+	message := i18n.FromRequest(r).Sprintf("You must be authenticated to access this resource")
+	ErrorMessageWithHeaders(w, r, http.StatusUnauthorized, message, headers)
 }
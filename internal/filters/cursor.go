@@ -0,0 +1,60 @@
+package filters
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// cursorTimeFormat is understood by MySQL when comparing against DATETIME and
+// TIMESTAMP columns.
+const cursorTimeFormat = "2006-01-02 15:04:05.999999"
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor identifies the last row of a keyset paginated page.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value any    `json:"v"`
+	ID    any    `json:"id"`
+}
+
+// EncodeCursor returns an opaque, URL safe cursor for the row with the given
+// sort column value and key column value.
+func EncodeCursor(sort string, value, id any) (string, error) {
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC().Format(cursorTimeFormat)
+	}
+
+	js, err := json.Marshal(Cursor{Sort: sort, Value: value, ID: id})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(js), nil
+}
+
+// DecodeCursor parses a cursor created by EncodeCursor. Numbers are decoded as
+// json.Number to avoid losing precision on large keys.
+func DecodeCursor(s string) (Cursor, error) {
+	js, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+
+	var c Cursor
+	if err := dec.Decode(&c); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	if c.Value == nil || c.ID == nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
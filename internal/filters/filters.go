@@ -0,0 +1,151 @@
+// Package filters provides pagination, sorting and filtering helpers for list
+// endpoints.
+package filters
+
+import (
+	"math"
+	"strings"
+
+	"github.com/grocky/go-api-starter/internal/validator"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	maxPage         = 10_000_000
+)
+
+// Filters holds the pagination and sorting options of a list request. The
+// exported fields carry `query` tags so they can be populated with
+// request.DecodeQuery.
+//
+// Sort names a column from SortSafelist, prefixed with "-" for descending
+// order. Cursor, when set, switches from page/page_size pagination to keyset
+// pagination starting after the row the cursor was created from.
+type Filters struct {
+	Page     int    `query:"page"`
+	PageSize int    `query:"page_size"`
+	Sort     string `query:"sort"`
+	Cursor   string `query:"cursor"`
+
+	SortSafelist []string
+}
+
+// New returns Filters with the default page size, sorted by the first entry of
+// sortSafelist.
+func New(sortSafelist ...string) Filters {
+	f := Filters{
+		Page:         1,
+		PageSize:     defaultPageSize,
+		SortSafelist: sortSafelist,
+	}
+
+	if len(sortSafelist) > 0 {
+		f.Sort = sortSafelist[0]
+	}
+
+	return f
+}
+
+// Validate checks the filters, adding field errors to v using the query
+// parameter names as keys.
+func (f Filters) Validate(v *validator.Validator) {
+	tooSmall := validator.Msg("must be greater than zero").WithCode(validator.CodeTooSmall, "min", 1)
+
+	v.CheckFieldMessage(f.Page > 0, "page", tooSmall)
+	v.CheckFieldMessage(f.Page <= maxPage, "page",
+		validator.Msg("must be a maximum of %d", maxPage).WithCode(validator.CodeTooLarge, "max", maxPage))
+	v.CheckFieldMessage(f.PageSize > 0, "page_size", tooSmall)
+	v.CheckFieldMessage(f.PageSize <= maxPageSize, "page_size",
+		validator.Msg("must be a maximum of %d", maxPageSize).WithCode(validator.CodeTooLarge, "max", maxPageSize))
+	v.CheckFieldMessage(validator.In(f.Sort, f.SortSafelist...), "sort",
+		validator.Msg("invalid sort value").WithCode(validator.CodeInvalidChoice, "values", f.SortSafelist))
+
+	if f.Cursor != "" {
+		c, err := DecodeCursor(f.Cursor)
+		v.CheckFieldf(err == nil, "cursor", "must be a valid cursor")
+		v.CheckFieldf(err != nil || c.Sort == f.Sort, "cursor", "does not match the sort value")
+	}
+}
+
+// SortColumn returns the column name of Sort without the direction prefix. It
+// panics if Sort is not in the safelist, as a last line of defence against
+// SQL injection.
+func (f Filters) SortColumn() string {
+	for _, safeValue := range f.SortSafelist {
+		if f.Sort == safeValue {
+			return strings.TrimPrefix(f.Sort, "-")
+		}
+	}
+
+	panic("unsafe sort parameter: " + f.Sort)
+}
+
+// SortDirection returns "ASC" or "DESC" depending on the prefix of Sort.
+func (f Filters) SortDirection() string {
+	if strings.HasPrefix(f.Sort, "-") {
+		return "DESC"
+	}
+
+	return "ASC"
+}
+
+// Limit returns the maximum number of records of a page.
+func (f Filters) Limit() int {
+	return f.PageSize
+}
+
+// Offset returns the number of records preceding the current page.
+func (f Filters) Offset() int {
+	return (f.Page - 1) * f.PageSize
+}
+
+// Metadata describes the page returned by a list endpoint.
+type Metadata struct {
+	CurrentPage  int    `json:"current_page,omitempty"`
+	PageSize     int    `json:"page_size,omitempty"`
+	FirstPage    int    `json:"first_page,omitempty"`
+	LastPage     int    `json:"last_page,omitempty"`
+	TotalRecords int    `json:"total_records,omitempty"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+}
+
+// CalculateMetadata returns the metadata of a page/page_size paginated
+// response. An empty Metadata is returned when there are no records.
+func CalculateMetadata(totalRecords, page, pageSize int) Metadata {
+	if totalRecords == 0 {
+		return Metadata{}
+	}
+
+	return Metadata{
+		CurrentPage:  page,
+		PageSize:     pageSize,
+		FirstPage:    1,
+		LastPage:     int(math.Ceil(float64(totalRecords) / float64(pageSize))),
+		TotalRecords: totalRecords,
+	}
+}
+
+// KeysetPage trims the look-ahead row fetched by a query built with
+// Builder.Keyset and returns the metadata of the page. key returns the sort
+// column value and the key column value of a record, which are used to build
+// the cursor of the next page.
+func KeysetPage[T any](records []T, f Filters, key func(T) (sortValue, id any)) ([]T, Metadata, error) {
+	metadata := Metadata{PageSize: f.PageSize}
+
+	if len(records) <= f.PageSize {
+		return records, metadata, nil
+	}
+
+	records = records[:f.PageSize]
+
+	sortValue, id := key(records[len(records)-1])
+	next, err := EncodeCursor(f.Sort, sortValue, id)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata.NextCursor = next
+
+	return records, metadata, nil
+}
@@ -0,0 +1,163 @@
+package filters
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/grocky/go-api-starter/internal/validator"
+)
+
+func TestFiltersValidate(t *testing.T) {
+	cursor, err := EncodeCursor("name", "bob", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(f *Filters)
+		want   []string
+	}{
+		{"defaults", func(*Filters) {}, nil},
+		{"zero page", func(f *Filters) { f.Page = 0 }, []string{"page"}},
+		{"huge page", func(f *Filters) { f.Page = maxPage + 1 }, []string{"page"}},
+		{"zero page size", func(f *Filters) { f.PageSize = 0 }, []string{"page_size"}},
+		{"huge page size", func(f *Filters) { f.PageSize = maxPageSize + 1 }, []string{"page_size"}},
+		{"unsafe sort", func(f *Filters) { f.Sort = "password_hash" }, []string{"sort"}},
+		{"cursor", func(f *Filters) { f.Cursor = cursor }, nil},
+		{"invalid cursor", func(f *Filters) { f.Cursor = "%%%" }, []string{"cursor"}},
+		{"cursor of another sort", func(f *Filters) { f.Sort, f.Cursor = "-name", cursor }, []string{"cursor"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New("name", "-name")
+			tt.modify(&f)
+
+			var v validator.Validator
+			f.Validate(&v)
+
+			var got []string
+			for key := range v.FieldErrors {
+				got = append(got, key)
+			}
+			slices.Sort(got)
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("field errors = %v, want %v", v.FieldErrors, tt.want)
+			}
+		})
+	}
+}
+
+func TestFiltersSort(t *testing.T) {
+	f := New("name", "-created_at")
+
+	if f.SortColumn() != "name" || f.SortDirection() != "ASC" {
+		t.Errorf("sort = %s %s, want name ASC", f.SortColumn(), f.SortDirection())
+	}
+
+	f.Sort = "-created_at"
+	if f.SortColumn() != "created_at" || f.SortDirection() != "DESC" {
+		t.Errorf("sort = %s %s, want created_at DESC", f.SortColumn(), f.SortDirection())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("SortColumn() did not panic outside the safelist")
+		}
+	}()
+
+	f.Sort = "id; DROP TABLE user"
+	f.SortColumn()
+}
+
+func TestCalculateMetadata(t *testing.T) {
+	tests := []struct {
+		total, page, pageSize int
+		want                  Metadata
+	}{
+		{0, 1, 20, Metadata{}},
+		{1, 1, 20, Metadata{CurrentPage: 1, PageSize: 20, FirstPage: 1, LastPage: 1, TotalRecords: 1}},
+		{41, 2, 20, Metadata{CurrentPage: 2, PageSize: 20, FirstPage: 1, LastPage: 3, TotalRecords: 41}},
+	}
+
+	for _, tt := range tests {
+		if got := CalculateMetadata(tt.total, tt.page, tt.pageSize); got != tt.want {
+			t.Errorf("CalculateMetadata(%d, %d, %d) = %+v, want %+v", tt.total, tt.page, tt.pageSize, got, tt.want)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name      string
+		value, id any
+		wantValue any
+		wantID    any
+	}{
+		{"string", "bob", "user-1", "bob", "user-1"},
+		{"large number", int64(1) << 60, 9007199254740993, json.Number("1152921504606846976"), json.Number("9007199254740993")},
+		{"time in UTC", at, 1, "2024-01-02 02:04:05.6", json.Number("1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := EncodeCursor("sort", tt.value, tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := DecodeCursor(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Sort != "sort" || c.Value != tt.wantValue || c.ID != tt.wantID {
+				t.Errorf("DecodeCursor() = %#v, want value %#v and id %#v", c, tt.wantValue, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsInvalidCursors(t *testing.T) {
+	for _, s := range []string{"", "!!", "bm90IGpzb24", "e30", `eyJzIjoibmFtZSIsInYiOiJib2IifQ`} {
+		if _, err := DecodeCursor(s); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestKeysetPage(t *testing.T) {
+	f := New("id")
+	f.PageSize = 2
+
+	key := func(id int) (any, any) { return id, id }
+
+	records, metadata, err := KeysetPage([]int{1, 2}, f, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || metadata.NextCursor != "" {
+		t.Errorf("last page = %v, %+v, want no next cursor", records, metadata)
+	}
+
+	records, metadata, err = KeysetPage([]int{1, 2, 3}, f, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(records, []int{1, 2}) {
+		t.Errorf("records = %v, want the look-ahead row trimmed", records)
+	}
+
+	c, err := DecodeCursor(metadata.NextCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Value != json.Number("2") || c.ID != json.Number("2") {
+		t.Errorf("next cursor = %#v, want the last record of the page", c)
+	}
+}
@@ -0,0 +1,119 @@
+package filters
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Builder composes the WHERE, ORDER BY and LIMIT clauses of a list query.
+// Conditions use `?` placeholders; slice arguments are expanded for `IN (?)`
+// with sqlx.In. The generated query should be passed through sqlx.DB.Rebind
+// before it is executed.
+//
+// Only the sort and key columns are interpolated into the SQL. The sort column
+// is taken from the filters' safelist and the key column is set by the caller,
+// so neither comes from user input.
+type Builder struct {
+	base       string
+	keyColumn  string
+	conditions []string
+	args       []any
+}
+
+// Select starts a query from base, which should be a SELECT statement without
+// WHERE, ORDER BY or LIMIT clauses. The key column, used to break ties when
+// sorting, defaults to "id".
+func Select(base string) *Builder {
+	return &Builder{
+		base:      base,
+		keyColumn: "id",
+	}
+}
+
+// KeyColumn sets the unique column used to break ties when sorting.
+func (b *Builder) KeyColumn(column string) *Builder {
+	b.keyColumn = column
+	return b
+}
+
+// Where adds a condition joined to the previous ones with AND. The condition
+// is parenthesized, so it may itself use OR.
+func (b *Builder) Where(condition string, args ...any) *Builder {
+	b.conditions = append(b.conditions, condition)
+	b.args = append(b.args, args...)
+	return b
+}
+
+// WhereIf adds the condition only when ok is true, which suits optional
+// filters.
+func (b *Builder) WhereIf(ok bool, condition string, args ...any) *Builder {
+	if !ok {
+		return b
+	}
+	return b.Where(condition, args...)
+}
+
+// Count returns a query selecting the number of rows matching the conditions.
+func (b *Builder) Count() (string, []any, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM (%s%s) AS filtered", b.base, b.where(nil))
+	return sqlx.In(query, b.args...)
+}
+
+// Page returns the query for the page described by f using LIMIT and OFFSET.
+func (b *Builder) Page(f Filters) (string, []any, error) {
+	query := b.base + b.where(nil) + b.orderBy(f) + " LIMIT ? OFFSET ?"
+	args := append(b.args[:len(b.args):len(b.args)], f.Limit(), f.Offset())
+
+	return sqlx.In(query, args...)
+}
+
+// Keyset returns the query for the page following f.Cursor, or the first page
+// if there is no cursor. One extra row is fetched so that KeysetPage can tell
+// whether there is a next page.
+func (b *Builder) Keyset(f Filters) (string, []any, error) {
+	args := b.args[:len(b.args):len(b.args)]
+
+	var keyset []string
+	if f.Cursor != "" {
+		c, err := DecodeCursor(f.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+
+		op := ">"
+		if f.SortDirection() == "DESC" {
+			op = "<"
+		}
+
+		column := f.SortColumn()
+		keyset = append(keyset, fmt.Sprintf("%s %s ? OR (%s = ? AND %s %s ?)", column, op, column, b.keyColumn, op))
+		args = append(args, c.Value, c.Value, c.ID)
+	}
+
+	query := b.base + b.where(keyset) + b.orderBy(f) + " LIMIT ?"
+	args = append(args, f.Limit()+1)
+
+	return sqlx.In(query, args...)
+}
+
+func (b *Builder) where(extra []string) string {
+	conditions := append(b.conditions[:len(b.conditions):len(b.conditions)], extra...)
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE (" + strings.Join(conditions, ") AND (") + ")"
+}
+
+func (b *Builder) orderBy(f Filters) string {
+	direction := f.SortDirection()
+	column := f.SortColumn()
+
+	if column == b.keyColumn {
+		return fmt.Sprintf(" ORDER BY %s %s", column, direction)
+	}
+
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s", column, direction, b.keyColumn, direction)
+}
@@ -0,0 +1,121 @@
+package filters
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestBuilderKeysetParenthesizesConditions(t *testing.T) {
+	cursor, err := EncodeCursor("-created_at", "2024-01-02 03:04:05", 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := New("-created_at", "created_at", "id")
+	f.PageSize = 10
+	f.Cursor = cursor
+
+	query, args, err := Select("SELECT id, created_at FROM document").
+		Where("owner_id = ? OR shared = ?", "user-1", true).
+		Where("archived = ?", false).
+		Keyset(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantQuery := "SELECT id, created_at FROM document" +
+		" WHERE (owner_id = ? OR shared = ?) AND (archived = ?)" +
+		" AND (created_at < ? OR (created_at = ? AND id < ?))" +
+		" ORDER BY created_at DESC, id DESC LIMIT ?"
+	if query != wantQuery {
+		t.Errorf("query =\n%s\nwant\n%s", query, wantQuery)
+	}
+
+	wantArgs := []any{"user-1", true, false, "2024-01-02 03:04:05", "2024-01-02 03:04:05", "42", 11}
+	if len(args) != len(wantArgs) {
+		t.Fatalf("args = %v, want %v", args, wantArgs)
+	}
+	for i := range args {
+		if got := toString(args[i]); got != toString(wantArgs[i]) {
+			t.Errorf("args[%d] = %v, want %v", i, args[i], wantArgs[i])
+		}
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		name      string
+		build     func() (string, []any, error)
+		wantQuery string
+		wantArgs  []any
+	}{
+		{
+			name: "no conditions",
+			build: func() (string, []any, error) {
+				return Select("SELECT id FROM user").Page(New("id"))
+			},
+			wantQuery: "SELECT id FROM user ORDER BY id ASC LIMIT ? OFFSET ?",
+			wantArgs:  []any{20, 0},
+		},
+		{
+			name: "page with IN expansion",
+			build: func() (string, []any, error) {
+				f := New("email", "id")
+				f.Page = 3
+				f.PageSize = 5
+				return Select("SELECT id FROM user").Where("id IN (?)", []string{"a", "b"}).Page(f)
+			},
+			wantQuery: "SELECT id FROM user WHERE (id IN (?, ?)) ORDER BY email ASC, id ASC LIMIT ? OFFSET ?",
+			wantArgs:  []any{"a", "b", 5, 10},
+		},
+		{
+			name: "skipped optional condition",
+			build: func() (string, []any, error) {
+				return Select("SELECT id FROM user").WhereIf(false, "activated = ?", true).Count()
+			},
+			wantQuery: "SELECT COUNT(*) FROM (SELECT id FROM user) AS filtered",
+			wantArgs:  nil,
+		},
+		{
+			name: "first keyset page",
+			build: func() (string, []any, error) {
+				f := New("id")
+				f.PageSize = 2
+				return Select("SELECT id FROM user").WhereIf(true, "a = ? OR b = ?", 1, 2).Keyset(f)
+			},
+			wantQuery: "SELECT id FROM user WHERE (a = ? OR b = ?) ORDER BY id ASC LIMIT ?",
+			wantArgs:  []any{1, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := tt.build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", query, tt.wantQuery)
+			}
+			if len(args) != 0 || len(tt.wantArgs) != 0 {
+				if !reflect.DeepEqual(args, tt.wantArgs) {
+					t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestKeysetRejectsInvalidCursor(t *testing.T) {
+	f := New("id")
+	f.Cursor = "not a cursor"
+
+	if _, _, err := Select("SELECT id FROM user").Keyset(f); err != ErrInvalidCursor {
+		t.Fatalf("Keyset() error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func toString(v any) string {
+	return fmt.Sprint(v)
+}
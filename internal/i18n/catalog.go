@@ -0,0 +1,159 @@
+package i18n
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message/catalog"
+)
+
+// messages holds the translations of every client facing message. English is
+// not listed as the keys are the English messages themselves.
+var messages = mustBuild(map[language.Tag]map[string]string{
+	language.Spanish: {
+		// server errors
+		"The server encountered a problem and could not process your request":              "El servidor encontró un problema y no pudo procesar su solicitud",
+		"The requested resource could not be found":                                        "No se pudo encontrar el recurso solicitado",
+		"The request conflicts with the current state of the resource, please try again":   "La solicitud entra en conflicto con el estado actual del recurso, inténtelo de nuevo",
+		"A resource with the same %s already exists":                                       "Ya existe un recurso con el mismo valor de %s",
+		"A value in the request is too long":                                               "Un valor de la solicitud es demasiado largo",
+		"The server timed out while processing your request, please try again":             "El servidor agotó el tiempo de espera al procesar su solicitud, inténtelo de nuevo",
+		"The %s method is not supported for this resource":                                 "El método %s no es compatible con este recurso",
+		"Invalid authentication token":                                                     "Token de autenticación no válido",
+		"You must be authenticated to access this resource":                                "Debe autenticarse para acceder a este recurso",
+		"Invalid authentication credentials":                                               "Credenciales de autenticación no válidas",
+		"Your user account must be activated to access this resource":                      "Su cuenta de usuario debe estar activada para acceder a este recurso",
+		"Your user account doesn't have the necessary permissions to access this resource": "Su cuenta de usuario no tiene los permisos necesarios para acceder a este recurso",
+		"Invalid or missing CSRF token":                                                    "Token CSRF no válido o ausente",
+		"This resource cannot be accessed with an API key":                                 "No se puede acceder a este recurso con una clave de API",
+
+		// validation
+		"must be provided":                         "es obligatorio",
+		"must be a valid email address":            "debe ser una dirección de correo electrónico válida",
+		"must be a valid URL":                      "debe ser una URL válida",
+		"must be at least %d characters long":      "debe tener al menos %d caracteres",
+		"must not be more than %d characters long": "no debe tener más de %d caracteres",
+		"must contain at least %d items":           "debe contener al menos %d elementos",
+		"must not contain more than %d items":      "no debe contener más de %d elementos",
+		"must be at least %v":                      "debe ser como mínimo %v",
+		"must not be more than %v":                 "no debe ser mayor que %v",
+		"must be one of %s":                        "debe ser uno de %s",
+		"must not contain duplicate values":        "no debe contener valores duplicados",
+		"must be greater than zero":                "debe ser mayor que cero",
+		"must be a maximum of %d":                  "debe ser como máximo %d",
+		"invalid sort value":                       "valor de ordenación no válido",
+		"must be a valid cursor":                   "debe ser un cursor válido",
+		"does not match the sort value":            "no coincide con el valor de ordenación",
+		"must be a valid value":                    "debe ser un valor válido",
+		"must be a valid time in the format %s":    "debe ser una hora válida con el formato %s",
+		"must be a valid duration":                 "debe ser una duración válida",
+		"must be a boolean value":                  "debe ser un valor booleano",
+		"must be an integer value":                 "debe ser un número entero",
+		"must be a positive integer value":         "debe ser un número entero positivo",
+		"must be a number":                         "debe ser un número",
+
+		// passwords
+		"must not be more than %d bytes long":                "no debe tener más de %d bytes",
+		"is too common":                                      "es demasiado común",
+		"is too similar to your personal information":        "es demasiado parecida a su información personal",
+		"must contain at least one uppercase letter":         "debe contener al menos una letra mayúscula",
+		"must contain at least one lowercase letter":         "debe contener al menos una letra minúscula",
+		"must contain at least one digit":                    "debe contener al menos un dígito",
+		"must contain at least one symbol":                   "debe contener al menos un símbolo",
+		"has appeared in a data breach and must not be used": "ha aparecido en una filtración de datos y no debe usarse",
+
+		// users
+		"a user with this email address already exists": "ya existe un usuario con esta dirección de correo electrónico",
+		"invalid or expired activation token":           "token de activación no válido o caducado",
+		"invalid or expired password reset token":       "token de restablecimiento de contraseña no válido o caducado",
+		"an email will be sent to you containing password reset instructions if an activated account exists for this email address": "si existe una cuenta activada con esta dirección de correo electrónico, recibirá un correo con las instrucciones para restablecer la contraseña",
+		"your password was successfully reset":                           "su contraseña se restableció correctamente",
+		"must be a known permission":                                     "debe ser un permiso conocido",
+		"must be a permission you hold":                                  "debe ser un permiso que usted tiene",
+		"invalid or expired two-factor token":                            "token de doble factor no válido o caducado",
+		"invalid two-factor code":                                        "código de doble factor no válido",
+		"two-factor authentication is already enabled":                   "la autenticación de doble factor ya está activada",
+		"two-factor authentication is not enabled":                       "la autenticación de doble factor no está activada",
+		"two-factor authentication must be enrolled and not yet enabled": "la autenticación de doble factor debe estar registrada y aún no activada",
+	},
+	language.French: {
+		// server errors
+		"The server encountered a problem and could not process your request":              "Le serveur a rencontré un problème et n'a pas pu traiter votre requête",
+		"The requested resource could not be found":                                        "La ressource demandée est introuvable",
+		"The request conflicts with the current state of the resource, please try again":   "La requête est en conflit avec l'état actuel de la ressource, veuillez réessayer",
+		"A resource with the same %s already exists":                                       "Une ressource avec la même valeur de %s existe déjà",
+		"A value in the request is too long":                                               "Une valeur de la requête est trop longue",
+		"The server timed out while processing your request, please try again":             "Le délai de traitement de votre requête a expiré, veuillez réessayer",
+		"The %s method is not supported for this resource":                                 "La méthode %s n'est pas prise en charge pour cette ressource",
+		"Invalid authentication token":                                                     "Jeton d'authentification invalide",
+		"You must be authenticated to access this resource":                                "Vous devez être authentifié pour accéder à cette ressource",
+		"Invalid authentication credentials":                                               "Identifiants d'authentification invalides",
+		"Your user account must be activated to access this resource":                      "Votre compte utilisateur doit être activé pour accéder à cette ressource",
+		"Your user account doesn't have the necessary permissions to access this resource": "Votre compte utilisateur ne dispose pas des autorisations nécessaires pour accéder à cette ressource",
+		"Invalid or missing CSRF token":                                                    "Jeton CSRF invalide ou manquant",
+		"This resource cannot be accessed with an API key":                                 "Cette ressource n'est pas accessible avec une clé d'API",
+
+		// validation
+		"must be provided":                         "est obligatoire",
+		"must be a valid email address":            "doit être une adresse e-mail valide",
+		"must be a valid URL":                      "doit être une URL valide",
+		"must be at least %d characters long":      "doit contenir au moins %d caractères",
+		"must not be more than %d characters long": "ne doit pas dépasser %d caractères",
+		"must contain at least %d items":           "doit contenir au moins %d éléments",
+		"must not contain more than %d items":      "ne doit pas contenir plus de %d éléments",
+		"must be at least %v":                      "doit être au moins %v",
+		"must not be more than %v":                 "ne doit pas dépasser %v",
+		"must be one of %s":                        "doit être l'une des valeurs suivantes : %s",
+		"must not contain duplicate values":        "ne doit pas contenir de doublons",
+		"must be greater than zero":                "doit être supérieur à zéro",
+		"must be a maximum of %d":                  "doit être au maximum %d",
+		"invalid sort value":                       "valeur de tri invalide",
+		"must be a valid cursor":                   "doit être un curseur valide",
+		"does not match the sort value":            "ne correspond pas à la valeur de tri",
+		"must be a valid value":                    "doit être une valeur valide",
+		"must be a valid time in the format %s":    "doit être une heure valide au format %s",
+		"must be a valid duration":                 "doit être une durée valide",
+		"must be a boolean value":                  "doit être une valeur booléenne",
+		"must be an integer value":                 "doit être un nombre entier",
+		"must be a positive integer value":         "doit être un nombre entier positif",
+		"must be a number":                         "doit être un nombre",
+
+		// passwords
+		"must not be more than %d bytes long":                "ne doit pas dépasser %d octets",
+		"is too common":                                      "est trop courant",
+		"is too similar to your personal information":        "est trop proche de vos informations personnelles",
+		"must contain at least one uppercase letter":         "doit contenir au moins une lettre majuscule",
+		"must contain at least one lowercase letter":         "doit contenir au moins une lettre minuscule",
+		"must contain at least one digit":                    "doit contenir au moins un chiffre",
+		"must contain at least one symbol":                   "doit contenir au moins un symbole",
+		"has appeared in a data breach and must not be used": "est apparu dans une fuite de données et ne doit pas être utilisé",
+
+		// users
+		"a user with this email address already exists": "un utilisateur avec cette adresse e-mail existe déjà",
+		"invalid or expired activation token":           "jeton d'activation invalide ou expiré",
+		"invalid or expired password reset token":       "jeton de réinitialisation du mot de passe invalide ou expiré",
+		"an email will be sent to you containing password reset instructions if an activated account exists for this email address": "si un compte activé existe pour cette adresse e-mail, vous recevrez un e-mail contenant les instructions de réinitialisation du mot de passe",
+		"your password was successfully reset":                           "votre mot de passe a été réinitialisé",
+		"must be a known permission":                                     "doit être une autorisation connue",
+		"must be a permission you hold":                                  "doit être une autorisation que vous détenez",
+		"invalid or expired two-factor token":                            "jeton de double authentification invalide ou expiré",
+		"invalid two-factor code":                                        "code de double authentification invalide",
+		"two-factor authentication is already enabled":                   "la double authentification est déjà activée",
+		"two-factor authentication is not enabled":                       "la double authentification n'est pas activée",
+		"two-factor authentication must be enrolled and not yet enabled": "la double authentification doit être enregistrée et pas encore activée",
+	},
+})
+
+func mustBuild(translations map[language.Tag]map[string]string) *catalog.Builder {
+	b := catalog.NewBuilder(catalog.Fallback(language.English))
+
+	for tag, dictionary := range translations {
+		for key, msg := range dictionary {
+			if err := b.SetString(tag, key, msg); err != nil {
+				panic(fmt.Sprintf("i18n: invalid %s translation of %q: %s", tag, key, err))
+			}
+		}
+	}
+
+	return b
+}
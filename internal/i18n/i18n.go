@@ -0,0 +1,64 @@
+// Package i18n provides the message catalog and language negotiation used to
+// localize client facing messages.
+package i18n
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// contextKey is a private string type to prevent collisions in the context map.
+type contextKey string
+
+// printerKey points to the value in the context where the printer is stored.
+const printerKey = contextKey("printer")
+
+// Supported lists the languages of the catalog. The first entry is the
+// fallback used when negotiation fails.
+var Supported = []language.Tag{
+	language.English,
+	language.Spanish,
+	language.French,
+}
+
+var matcher = language.NewMatcher(Supported)
+
+// Negotiate returns the supported language which best matches the given
+// Accept-Language header value, falling back to English.
+func Negotiate(acceptLanguage string) language.Tag {
+	_, index := language.MatchStrings(matcher, acceptLanguage)
+	return Supported[index]
+}
+
+// NewPrinter returns a printer which translates messages into the given
+// language using the catalog. Messages missing from the catalog are formatted
+// as given, so the English text doubles as the message key.
+func NewPrinter(tag language.Tag) *message.Printer {
+	return message.NewPrinter(tag, message.Catalog(messages))
+}
+
+func WithPrinter(ctx context.Context, p *message.Printer) context.Context {
+	return context.WithValue(ctx, printerKey, p)
+}
+
+// FromContext returns the printer stored in ctx, or an English printer if
+// there is none.
+func FromContext(ctx context.Context) *message.Printer {
+	if p, ok := ctx.Value(printerKey).(*message.Printer); ok {
+		return p
+	}
+	return NewPrinter(language.English)
+}
+
+// FromRequest returns the printer stored in the request context. Handlers
+// which run outside the router's middleware chain, such as the not found
+// handler, fall back to negotiating the Accept-Language header.
+func FromRequest(r *http.Request) *message.Printer {
+	if p, ok := r.Context().Value(printerKey).(*message.Printer); ok {
+		return p
+	}
+	return NewPrinter(Negotiate(r.Header.Get("Accept-Language")))
+}
@@ -0,0 +1,153 @@
+// Package jobs runs background jobs from a persistent queue. Jobs are leased
+// by a worker for the time they run and the lease is extended by heartbeats,
+// so that the jobs of a worker which crashed are picked up again once their
+// lease expires.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+var (
+	// ErrDuplicate is returned when enqueueing a job whose unique key is held
+	// by a job which has not completed yet.
+	ErrDuplicate = errors.New("jobs: duplicate job")
+	// ErrLeaseLost is returned when a job is no longer leased by the worker,
+	// which happens when its lease expired and another worker claimed it.
+	ErrLeaseLost = errors.New("jobs: lease lost")
+	// ErrLeaseExpired is recorded as the cause of a job whose lease expired
+	// on its last attempt, usually because its worker crashed.
+	ErrLeaseExpired = errors.New("jobs: lease expired on the last attempt")
+)
+
+// Job is a unit of work in the queue. Attempts counts the runs started,
+// including the current one once claimed.
+type Job struct {
+	ID          int64           `db:"id"`
+	Kind        string          `db:"kind"`
+	Args        json.RawMessage `db:"args"`
+	Priority    int             `db:"priority"`
+	UniqueKey   string          `db:"unique_key"`
+	Attempts    int             `db:"attempts"`
+	MaxAttempts int             `db:"max_attempts"`
+	RunAt       time.Time       `db:"run_at"`
+	CreatedAt   time.Time       `db:"created_at"`
+}
+
+// Store persists the queue. Every method but Insert and Claim only affects a
+// job still leased by owner, and returns ErrLeaseLost otherwise.
+type Store interface {
+	// Insert adds the job, setting its ID. ErrDuplicate is returned when
+	// another job holds its unique key.
+	Insert(ctx context.Context, job *Job) error
+	// Claim leases up to limit jobs to owner, either due or whose lease
+	// expired, highest priority first, and counts an attempt for each. A job
+	// whose lease expired on its last attempt is failed with ErrLeaseExpired
+	// rather than claimed.
+	Claim(ctx context.Context, owner string, limit int, lease time.Duration) ([]*Job, error)
+	// Heartbeat extends the lease of the job.
+	Heartbeat(ctx context.Context, id int64, owner string, lease time.Duration) error
+	// Complete removes the job, releasing its unique key.
+	Complete(ctx context.Context, id int64, owner string) error
+	// Retry records the failed run and makes the job due again at.
+	Retry(ctx context.Context, id int64, owner string, at time.Time, cause error) error
+	// Fail records the failed run and sets the job aside for good, releasing
+	// its unique key.
+	Fail(ctx context.Context, id int64, owner string, cause error) error
+}
+
+// Args are the arguments of a job, encoded in JSON. Kind names the job and
+// its handler. It is called on the zero value, so Args are usually structs
+// with a value receiver:
+//
+//	type SendReportArgs struct {
+//		UserID string `json:"user_id"`
+//	}
+//
+//	func (SendReportArgs) Kind() string { return "send-report" }
+type Args interface {
+	Kind() string
+}
+
+// EnqueueOptions tune a job. The zero value runs the job as soon as possible
+// with the default priority and attempts of the queue.
+type EnqueueOptions struct {
+	// Delay postpones the first run.
+	Delay time.Duration
+	// Priority orders the due jobs, higher first.
+	Priority int
+	// UniqueKey prevents enqueueing another job with the same key until
+	// this one has completed or failed for good.
+	UniqueKey string
+	// MaxAttempts overrides the MaxAttempts of the queue.
+	MaxAttempts int
+}
+
+// Enqueue adds a job with the arguments to the queue. ErrDuplicate is returned
+// when the unique key of the options is already held, which callers usually
+// treat as success.
+func (q *Queue) Enqueue(ctx context.Context, args Args, opts EnqueueOptions) (*Job, error) {
+	b, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	job := &Job{
+		Kind:        args.Kind(),
+		Args:        b,
+		Priority:    opts.Priority,
+		UniqueKey:   opts.UniqueKey,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       now.Add(opts.Delay),
+		CreatedAt:   now,
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = q.MaxAttempts
+	}
+
+	if err := q.Store.Insert(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if opts.Delay <= 0 {
+		q.Notify()
+	}
+
+	return job, nil
+}
+
+// Handle registers fn as the handler of the jobs of kind T.
+func Handle[T Args](q *Queue, fn func(ctx context.Context, args T) error) {
+	var zero T
+
+	q.handlers[zero.Kind()] = func(ctx context.Context, raw json.RawMessage) error {
+		var args T
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return fmt.Errorf("jobs: invalid %s args: %w", zero.Kind(), err)
+		}
+
+		return fn(ctx, args)
+	}
+}
+
+// workerID identifies the process leasing jobs.
+func workerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
@@ -0,0 +1,239 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grocky/go-api-starter/internal/log"
+)
+
+type handler func(ctx context.Context, args json.RawMessage) error
+
+// Queue enqueues jobs and runs them with a pool of workers.
+type Queue struct {
+	Store Store
+
+	// Concurrency is the number of jobs run at once.
+	Concurrency int
+	// PollInterval is the time between two claims when no job is due,
+	// unless a job is enqueued by this queue.
+	PollInterval time.Duration
+	// Lease is how long a job stays with a worker without a heartbeat.
+	// Heartbeats are sent every third of it.
+	Lease time.Duration
+
+	// MaxAttempts is the default number of runs before a job fails for good.
+	// Failed runs are retried after RetryDelay, doubling after each attempt
+	// up to MaxRetryDelay.
+	MaxAttempts   int
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+
+	// DrainTimeout is how long the jobs running when the queue stops are
+	// given to complete before being canceled.
+	DrainTimeout time.Duration
+
+	owner    string
+	handlers map[string]handler
+	notify   chan struct{}
+}
+
+// New returns a queue of the jobs of the store.
+func New(store Store) *Queue {
+	return &Queue{
+		Store:         store,
+		Concurrency:   4,
+		PollInterval:  time.Second,
+		Lease:         30 * time.Second,
+		MaxAttempts:   10,
+		RetryDelay:    5 * time.Second,
+		MaxRetryDelay: time.Hour,
+		DrainTimeout:  15 * time.Second,
+		owner:         workerID(),
+		handlers:      make(map[string]handler),
+		notify:        make(chan struct{}, 1),
+	}
+}
+
+// Notify wakes the workers up, to run jobs just enqueued without waiting for
+// the next poll.
+func (q *Queue) Notify() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run runs jobs until ctx is done, then waits for the running jobs to drain.
+// It panics when Concurrency is not positive, as no job would ever run.
+func (q *Queue) Run(ctx context.Context) {
+	if q.Concurrency <= 0 {
+		panic(fmt.Sprintf("jobs: invalid concurrency %d", q.Concurrency))
+	}
+
+	logger := log.FromContext(ctx).Named("jobs").With("worker", q.owner)
+	ctx = log.WithLogger(ctx, logger)
+
+	// Running jobs outlive ctx, up to the drain timeout.
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	slots := make(chan struct{}, q.Concurrency)
+	done := make(chan struct{}, 1)
+	var wg sync.WaitGroup
+
+	for ctx.Err() == nil {
+		if free := cap(slots) - len(slots); free > 0 {
+			jobs, err := q.Store.Claim(ctx, q.owner, free, q.Lease)
+			if err != nil && ctx.Err() == nil {
+				logger.Error("unable to claim jobs", "error", err)
+			}
+
+			for _, job := range jobs {
+				slots <- struct{}{}
+				wg.Add(1)
+
+				go func() {
+					defer func() {
+						<-slots
+						wg.Done()
+
+						select {
+						case done <- struct{}{}:
+						default:
+						}
+					}()
+
+					q.work(jobCtx, job)
+				}()
+			}
+
+			if err == nil && len(jobs) == free {
+				continue
+			}
+		}
+
+		timer := time.NewTimer(q.PollInterval)
+		select {
+		case <-ctx.Done():
+		case <-q.notify:
+		case <-done:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	if len(slots) > 0 {
+		logger.Info("draining running jobs", "jobs", len(slots))
+	}
+
+	select {
+	case <-drained:
+	case <-time.After(q.DrainTimeout):
+		logger.Warn("canceling the jobs still running", "jobs", len(slots))
+		cancelJobs()
+		<-drained
+	}
+}
+
+// work runs the job while sending heartbeats, and records the outcome.
+func (q *Queue) work(ctx context.Context, job *Job) {
+	logger := log.FromContext(ctx).With("jobId", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var lost bool
+	var heartbeats sync.WaitGroup
+	heartbeats.Add(1)
+
+	go func() {
+		defer heartbeats.Done()
+
+		ticker := time.NewTicker(q.Lease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			err := q.Store.Heartbeat(runCtx, job.ID, q.owner, q.Lease)
+			switch {
+			case errors.Is(err, ErrLeaseLost):
+				logger.Warn("job lease lost, canceling the job")
+				lost = true
+				cancel()
+				return
+			case err != nil && runCtx.Err() == nil:
+				logger.Warn("unable to send job heartbeat", "error", err)
+			}
+		}
+	}()
+
+	start := time.Now()
+	err := q.run(runCtx, job)
+	cancel()
+	heartbeats.Wait()
+
+	if lost {
+		return
+	}
+
+	// The outcome is recorded even when the job was canceled by the drain.
+	ctx = context.WithoutCancel(ctx)
+
+	switch {
+	case err == nil:
+		logger.Info("job completed", "duration", time.Since(start).String())
+		err = q.Store.Complete(ctx, job.ID, q.owner)
+	case job.Attempts >= job.MaxAttempts:
+		logger.Error("job failed", "error", err)
+		err = q.Store.Fail(ctx, job.ID, q.owner, err)
+	default:
+		at := time.Now().Add(q.retryDelay(job.Attempts))
+		logger.Warn("job failed, retrying", "retryAt", at, "error", err)
+		err = q.Store.Retry(ctx, job.ID, q.owner, at, err)
+	}
+
+	if err != nil {
+		logger.Error("unable to record the job outcome", "error", err)
+	}
+}
+
+func (q *Queue) run(ctx context.Context, job *Job) (err error) {
+	h, ok := q.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("jobs: no handler for kind %q", job.Kind)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("jobs: handler panic: %v", p)
+		}
+	}()
+
+	return h(ctx, job.Args)
+}
+
+// retryDelay returns the delay before the attempt following the given one.
+func (q *Queue) retryDelay(attempts int) time.Duration {
+	delay := q.RetryDelay
+	for i := 1; i < attempts && delay < q.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, q.MaxRetryDelay)
+}
@@ -0,0 +1,195 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grocky/go-api-starter/internal/log"
+)
+
+// memoryStore records the outcome of the runs of its jobs.
+type memoryStore struct {
+	mu       sync.Mutex
+	pending  []*Job
+	outcomes map[int64]string
+	causes   map[int64]error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{outcomes: map[int64]string{}, causes: map[int64]error{}}
+}
+
+func (s *memoryStore) Insert(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job.ID = int64(len(s.pending) + len(s.outcomes) + 1)
+	s.pending = append(s.pending, job)
+	return nil
+}
+
+func (s *memoryStore) Claim(_ context.Context, _ string, limit int, _ time.Duration) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := min(limit, len(s.pending))
+	claimed := s.pending[:n]
+	s.pending = s.pending[n:]
+
+	for _, job := range claimed {
+		job.Attempts++
+	}
+
+	return claimed, nil
+}
+
+func (s *memoryStore) Heartbeat(context.Context, int64, string, time.Duration) error {
+	return nil
+}
+
+func (s *memoryStore) Complete(_ context.Context, id int64, _ string) error {
+	return s.record(id, "completed", nil)
+}
+
+func (s *memoryStore) Retry(_ context.Context, id int64, _ string, _ time.Time, cause error) error {
+	return s.record(id, "retried", cause)
+}
+
+func (s *memoryStore) Fail(_ context.Context, id int64, _ string, cause error) error {
+	return s.record(id, "failed", cause)
+}
+
+func (s *memoryStore) record(id int64, outcome string, cause error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.outcomes[id] = outcome
+	s.causes[id] = cause
+	return nil
+}
+
+type testArgs struct {
+	Fail  bool `json:"fail"`
+	Panic bool `json:"panic"`
+}
+
+func (testArgs) Kind() string { return "test" }
+
+type unknownArgs struct{}
+
+func (unknownArgs) Kind() string { return "unknown" }
+
+// quiet returns a context whose logger discards the records.
+func quiet() context.Context {
+	return log.WithLogger(context.Background(), log.New(io.Discard, slog.LevelError))
+}
+
+func TestQueueWork(t *testing.T) {
+	errRun := errors.New("run failed")
+
+	tests := []struct {
+		name        string
+		args        Args
+		attempts    int
+		maxAttempts int
+		want        string
+	}{
+		{"completed", testArgs{}, 0, 3, "completed"},
+		{"failed", testArgs{Fail: true}, 0, 3, "retried"},
+		{"failed for the last time", testArgs{Fail: true}, 2, 3, "failed"},
+		{"panicked", testArgs{Panic: true}, 0, 3, "retried"},
+		{"unknown kind", unknownArgs{}, 0, 3, "retried"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			q := New(store)
+			Handle(q, func(_ context.Context, args testArgs) error {
+				if args.Panic {
+					panic("boom")
+				}
+				if args.Fail {
+					return errRun
+				}
+				return nil
+			})
+
+			ctx := quiet()
+			job, err := q.Enqueue(ctx, tt.args, EnqueueOptions{MaxAttempts: tt.maxAttempts})
+			if err != nil {
+				t.Fatal(err)
+			}
+			job.Attempts = tt.attempts
+
+			claimed, err := store.Claim(ctx, q.owner, 1, q.Lease)
+			if err != nil || len(claimed) != 1 {
+				t.Fatalf("Claim() = %v, %v", claimed, err)
+			}
+
+			q.work(ctx, claimed[0])
+
+			if got := store.outcomes[job.ID]; got != tt.want {
+				t.Errorf("job %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueEnqueueDefaults(t *testing.T) {
+	q := New(newMemoryStore())
+
+	job, err := q.Enqueue(context.Background(), testArgs{}, EnqueueOptions{Delay: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Kind != "test" {
+		t.Errorf("Kind = %q, want %q", job.Kind, "test")
+	}
+	if job.MaxAttempts != q.MaxAttempts {
+		t.Errorf("MaxAttempts = %d, want %d", job.MaxAttempts, q.MaxAttempts)
+	}
+	if got := job.RunAt.Sub(job.CreatedAt); got != time.Minute {
+		t.Errorf("RunAt - CreatedAt = %v, want %v", got, time.Minute)
+	}
+}
+
+func TestQueueRunRejectsInvalidConcurrency(t *testing.T) {
+	q := New(newMemoryStore())
+	q.Concurrency = 0
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Run() did not panic")
+		}
+	}()
+
+	q.Run(quiet())
+}
+
+func TestQueueRetryDelay(t *testing.T) {
+	q := &Queue{RetryDelay: 5 * time.Second, MaxRetryDelay: 30 * time.Second}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{4, 30 * time.Second},
+		{50, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := q.retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package jwt issues and verifies compact JSON Web Tokens signed with HS256 or
+// EdDSA (Ed25519).
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("jwt: invalid token")
+	ErrExpired      = errors.New("jwt: token expired")
+	ErrUnknownKey   = errors.New("jwt: unknown signing key")
+)
+
+// leeway is the clock skew tolerated when checking time based claims.
+const leeway = 30 * time.Second
+
+// Claims are the registered claims used by the application. Times are encoded
+// as seconds since the Unix epoch.
+type Claims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ID        string `json:"jti,omitempty"`
+}
+
+// NewClaims returns claims for subject which are valid from now for ttl.
+func NewClaims(issuer, subject string, ttl time.Duration) Claims {
+	now := time.Now()
+
+	return Claims{
+		Issuer:    issuer,
+		Subject:   subject,
+		Audience:  issuer,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+}
+
+// Valid checks the time based claims at now. The exp claim is required, so
+// that no token is valid forever.
+func (c Claims) Valid(now time.Time) error {
+	if c.ExpiresAt == 0 {
+		return fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	if now.Add(-leeway).Unix() >= c.ExpiresAt {
+		return ErrExpired
+	}
+	if c.NotBefore != 0 && now.Add(leeway).Unix() < c.NotBefore {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	return nil
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// Sign returns the compact serialization of claims signed with the signing key
+// of the set. The key id is written to the kid header so that verifiers can
+// pick the right key after a rotation.
+func (ks *KeySet) Sign(claims Claims) (string, error) {
+	key := ks.signing
+
+	h, err := json.Marshal(header{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encode(h) + "." + encode(c)
+
+	signature, err := key.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + encode(signature), nil
+}
+
+// Verify checks the signature of token with the key named by its kid header,
+// and its time based claims, returning the claims when it is valid. The alg
+// header must match the algorithm of the key.
+func (ks *KeySet) Verify(token string, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeJSON(parts[0], &h); err != nil {
+		return Claims{}, err
+	}
+
+	key, ok := ks.keys[h.KeyID]
+	if !ok {
+		return Claims{}, ErrUnknownKey
+	}
+	if h.Algorithm != key.Algorithm {
+		return Claims{}, fmt.Errorf("%w: unexpected algorithm %q", ErrInvalidToken, h.Algorithm)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if !key.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return Claims{}, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var claims Claims
+	if err := decodeJSON(parts[1], &claims); err != nil {
+		return Claims{}, err
+	}
+
+	if err := claims.Valid(now); err != nil {
+		return Claims{}, err
+	}
+
+	return claims, nil
+}
+
+// LooksLikeJWT reports whether token has the three part shape of a compact
+// JWT, which distinguishes it from opaque tokens.
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeJSON(part string, dst any) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if err := dec.Decode(dst); err != nil {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testKeySet(t *testing.T) *KeySet {
+	t.Helper()
+
+	hs, err := NewHS256Key("hs", bytes.Repeat([]byte("k"), 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed, err := NewEdDSAKey("ed", bytes.Repeat([]byte("s"), 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := NewKeySet("ed", hs, ed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ks
+}
+
+func TestSignVerify(t *testing.T) {
+	ks := testKeySet(t)
+	now := time.Now()
+
+	hsOnly, err := NewKeySet("hs", ks.keys["hs"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		signer  *KeySet
+		claims  Claims
+		at      time.Time
+		wantErr error
+	}{
+		{
+			name:   "valid EdDSA",
+			signer: ks,
+			claims: NewClaims("api", "user-1", time.Minute),
+			at:     now,
+		},
+		{
+			name:   "valid HS256",
+			signer: hsOnly,
+			claims: NewClaims("api", "user-1", time.Minute),
+			at:     now,
+		},
+		{
+			name:   "expired within leeway",
+			signer: ks,
+			claims: NewClaims("api", "user-1", time.Minute),
+			at:     now.Add(time.Minute + leeway/2),
+		},
+		{
+			name:    "expired",
+			signer:  ks,
+			claims:  NewClaims("api", "user-1", time.Minute),
+			at:      now.Add(time.Minute + 2*leeway),
+			wantErr: ErrExpired,
+		},
+		{
+			name:    "not valid yet",
+			signer:  ks,
+			claims:  Claims{Subject: "user-1", NotBefore: now.Add(time.Hour).Unix(), ExpiresAt: now.Add(2 * time.Hour).Unix()},
+			at:      now,
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "missing exp",
+			signer:  ks,
+			claims:  Claims{Subject: "user-1", IssuedAt: now.Unix()},
+			at:      now,
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.signer.Sign(tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !LooksLikeJWT(token) {
+				t.Fatalf("Sign() = %q, not a compact JWT", token)
+			}
+
+			claims, err := ks.Verify(token, tt.at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && claims != tt.claims {
+				t.Errorf("Verify() = %+v, want %+v", claims, tt.claims)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsTamperedTokens(t *testing.T) {
+	ks := testKeySet(t)
+	now := time.Now()
+
+	token, err := ks.Sign(NewClaims("api", "user-1", time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+
+	otherClaims, err := ks.Sign(NewClaims("api", "admin", time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unknownKey := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA","typ":"JWT","kid":"gone"}`))
+	wrongAlg := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT","kid":"ed"}`))
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"two parts", parts[0] + "." + parts[1], ErrInvalidToken},
+		{"swapped claims", parts[0] + "." + strings.Split(otherClaims, ".")[1] + "." + parts[2], ErrInvalidToken},
+		{"bad signature encoding", parts[0] + "." + parts[1] + ".!", ErrInvalidToken},
+		{"unknown key", unknownKey + "." + parts[1] + "." + parts[2], ErrUnknownKey},
+		{"algorithm mismatch", wrongAlg + "." + parts[1] + "." + parts[2], ErrInvalidToken},
+		{"bad header", "e30." + parts[1] + "." + parts[2], ErrUnknownKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ks.Verify(tt.token, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
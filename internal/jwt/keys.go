@@ -0,0 +1,201 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// minHMACKeyLength is the minimum HS256 secret length, the size of the hash
+// output as recommended by RFC 7518.
+const minHMACKeyLength = 32
+
+// Key is a named signing key.
+type Key struct {
+	ID        string
+	Algorithm string
+
+	secret     []byte
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// NewHS256Key returns a symmetric key. The secret must be at least 32 bytes.
+func NewHS256Key(id string, secret []byte) (*Key, error) {
+	if len(secret) < minHMACKeyLength {
+		return nil, fmt.Errorf("jwt: HS256 key %q must be at least %d bytes", id, minHMACKeyLength)
+	}
+
+	return &Key{ID: id, Algorithm: AlgorithmHS256, secret: secret}, nil
+}
+
+// NewEdDSAKey returns an Ed25519 key from its 32 byte seed.
+func NewEdDSAKey(id string, seed []byte) (*Key, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("jwt: EdDSA key %q must be a %d byte seed", id, ed25519.SeedSize)
+	}
+
+	privateKey := ed25519.NewKeyFromSeed(seed)
+
+	return &Key{
+		ID:         id,
+		Algorithm:  AlgorithmEdDSA,
+		privateKey: privateKey,
+		publicKey:  privateKey.Public().(ed25519.PublicKey),
+	}, nil
+}
+
+// GenerateEdDSAKey returns a new random Ed25519 key.
+func GenerateEdDSAKey(id string) (*Key, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+
+	return NewEdDSAKey(id, seed)
+}
+
+func (k *Key) sign(signingInput []byte) ([]byte, error) {
+	switch k.Algorithm {
+	case AlgorithmHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(signingInput)
+		return mac.Sum(nil), nil
+	case AlgorithmEdDSA:
+		return ed25519.Sign(k.privateKey, signingInput), nil
+	}
+
+	return nil, fmt.Errorf("jwt: unsupported algorithm %q", k.Algorithm)
+}
+
+func (k *Key) verify(signingInput, signature []byte) bool {
+	switch k.Algorithm {
+	case AlgorithmHS256:
+		expected, _ := k.sign(signingInput)
+		return hmac.Equal(expected, signature)
+	case AlgorithmEdDSA:
+		return ed25519.Verify(k.publicKey, signingInput, signature)
+	}
+
+	return false
+}
+
+// KeySet holds the key used to sign new tokens and every key which is still
+// accepted when verifying. Rotating keys is done by adding a new key, making it
+// the signing key, and removing the previous key once the tokens it signed have
+// expired.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewKeySet returns a set signing with the key named signingKeyID.
+func NewKeySet(signingKeyID string, keys ...*Key) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key, len(keys))}
+
+	for _, key := range keys {
+		if _, dup := ks.keys[key.ID]; dup {
+			return nil, fmt.Errorf("jwt: duplicate key id %q", key.ID)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	signing, ok := ks.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("jwt: signing key %q is not in the key set", signingKeyID)
+	}
+	ks.signing = signing
+
+	return ks, nil
+}
+
+// ParseKeys parses a comma separated list of keys in the form
+// "kid:algorithm:base64", where the base64 (standard encoding) value is the
+// HS256 secret or the Ed25519 seed. For example:
+//
+//	2024-10:EdDSA:q1...=,2024-04:HS256:c2...=
+func ParseKeys(spec string) ([]*Key, error) {
+	var keys []*Key
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return nil, errors.New("jwt: keys must be in the form kid:algorithm:base64")
+		}
+
+		material, err := base64.StdEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("jwt: key %q is not valid base64: %w", parts[0], err)
+		}
+
+		var key *Key
+		switch parts[1] {
+		case AlgorithmHS256:
+			key, err = NewHS256Key(parts[0], material)
+		case AlgorithmEdDSA:
+			key, err = NewEdDSAKey(parts[0], material)
+		default:
+			err = fmt.Errorf("jwt: key %q has unsupported algorithm %q", parts[0], parts[1])
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// JWK is the public JSON Web Key representation of an Ed25519 key (RFC 8037).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set. Symmetric keys are never published,
+// so tokens signed with HS256 can only be verified by this service.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range ks.keys {
+		if key.Algorithm != AlgorithmEdDSA {
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key.publicKey),
+			KeyID:     key.ID,
+			Use:       "sig",
+			Algorithm: AlgorithmEdDSA,
+		})
+	}
+
+	return jwks
+}
@@ -80,7 +80,7 @@ func (l *Logger) Error(msg string, args ...any) {
 
 func (l *Logger) With(args ...any) *Logger {
 	c := l.clone()
-	c.l = c.l.With(args)
+	c.l = c.l.With(args...)
 
 	return c
 }
@@ -0,0 +1,232 @@
+package mysql
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// apiKeyPrefix starts every API key so that leaked keys are easy to recognize,
+// for example by secret scanners.
+const apiKeyPrefix = "gas"
+
+// apiKeyLastUsedInterval is the minimum time between two updates of the
+// last_used_at column of a key, which saves a write on every request.
+const apiKeyLastUsedInterval = time.Minute
+
+var apiKeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// APIKey is a long lived credential for machine clients. The key is
+// "gas_<prefix>_<secret>": the prefix is stored in clear to find the key and
+// to let users tell their keys apart, while only the SHA-256 hash of the whole
+// key is stored. A key grants at most its scopes, and only those the user
+// still holds.
+type APIKey struct {
+	ID         string       `db:"id" json:"id"`
+	Plaintext  string       `db:"-" json:"key,omitempty"`
+	Prefix     string       `db:"prefix" json:"prefix"`
+	Hash       []byte       `db:"hash" json:"-"`
+	UserID     string       `db:"user_id" json:"-"`
+	Name       string       `db:"name" json:"name"`
+	Scopes     Permissions  `db:"scopes" json:"scopes"`
+	Expiry     sql.NullTime `db:"expiry" json:"-"`
+	LastUsedAt sql.NullTime `db:"last_used_at" json:"-"`
+	CreatedAt  time.Time    `db:"created_at" json:"created_at"`
+}
+
+// MarshalJSON renders the nullable times as null or a timestamp.
+func (k APIKey) MarshalJSON() ([]byte, error) {
+	type apiKey APIKey
+
+	return json.Marshal(struct {
+		apiKey
+		Expiry     *time.Time `json:"expiry"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+	}{
+		apiKey:     apiKey(k),
+		Expiry:     nullTimePtr(k.Expiry),
+		LastUsedAt: nullTimePtr(k.LastUsedAt),
+	})
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// Scan reads permissions stored as a JSON array.
+func (p *Permissions) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	case nil:
+		*p = Permissions{}
+		return nil
+	default:
+		return fmt.Errorf("mysql: cannot scan %T into Permissions", src)
+	}
+
+	return json.Unmarshal(b, p)
+}
+
+// Value stores permissions as a JSON array.
+func (p Permissions) Value() (driver.Value, error) {
+	if p == nil {
+		p = Permissions{}
+	}
+
+	b, err := json.Marshal([]string(p))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// NewAPIKey generates and stores a key for the user. A zero ttl creates a key
+// which never expires.
+func (db *DB) NewAPIKey(ctx context.Context, userID, name string, scopes Permissions, ttl time.Duration) (*APIKey, error) {
+	prefix, err := randomBase32(5)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := randomBase32(20)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := apiKeyPrefix + "_" + prefix + "_" + secret
+
+	key := &APIKey{
+		ID:        uuid.NewString(),
+		Plaintext: plaintext,
+		Prefix:    prefix,
+		Hash:      HashToken(plaintext),
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if ttl > 0 {
+		key.Expiry = sql.NullTime{Time: key.CreatedAt.Add(ttl), Valid: true}
+	}
+
+	query := `
+		INSERT INTO api_key (id, prefix, hash, user_id, name, scopes, expiry, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err = db.ExecContext(ctx, query, key.ID, key.Prefix, key.Hash, key.UserID, key.Name, key.Scopes, key.Expiry, key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// GetAPIKeysForUser returns the keys of the user, newest first.
+func (db *DB) GetAPIKeysForUser(ctx context.Context, userID string) ([]*APIKey, error) {
+	query := `
+		SELECT id, prefix, hash, user_id, name, scopes, expiry, last_used_at, created_at
+		FROM api_key
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id`
+
+	keys := []*APIKey{}
+	if err := db.SelectContext(ctx, &keys, query, userID); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// DeleteAPIKey revokes the key of the user. ErrRecordNotFound is returned when
+// the user has no such key.
+func (db *DB) DeleteAPIKey(ctx context.Context, userID, id string) error {
+	result, err := db.ExecContext(ctx, `DELETE FROM api_key WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAPIKey returns the unexpired key matching plaintext along with the user
+// owning it, and records the key as used. ErrRecordNotFound is returned when
+// there is no such key. It reads from the primary, so that a revoked key is
+// rejected despite the replication lag.
+func (db *DB) GetAPIKey(ctx context.Context, plaintext string) (*APIKey, *User, error) {
+	ctx = WithPrimary(ctx)
+
+	parts := strings.Split(plaintext, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return nil, nil, ErrRecordNotFound
+	}
+
+	var key APIKey
+	query := `
+		SELECT id, prefix, hash, user_id, name, scopes, expiry, last_used_at, created_at
+		FROM api_key
+		WHERE prefix = ?`
+
+	if err := db.GetContext(ctx, &key, query, parts[1]); err != nil {
+		return nil, nil, err
+	}
+
+	if subtle.ConstantTimeCompare(key.Hash, HashToken(plaintext)) != 1 {
+		return nil, nil, ErrRecordNotFound
+	}
+
+	now := time.Now().UTC()
+	if key.Expiry.Valid && !key.Expiry.Time.After(now) {
+		return nil, nil, ErrRecordNotFound
+	}
+
+	user, err := db.GetUser(ctx, key.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !key.LastUsedAt.Valid || now.Sub(key.LastUsedAt.Time) >= apiKeyLastUsedInterval {
+		query := `
+			UPDATE api_key SET last_used_at = ?
+			WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)`
+
+		if _, err := db.ExecContext(ctx, query, now, key.ID, now.Add(-apiKeyLastUsedInterval)); err != nil {
+			return nil, nil, err
+		}
+		key.LastUsedAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	return &key, user, nil
+}
+
+func randomBase32(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(apiKeyEncoding.EncodeToString(b)), nil
+}
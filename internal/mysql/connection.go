@@ -2,11 +2,15 @@ package mysql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/go-sql-driver/mysql"
 	"github.com/grocky/go-api-starter/internal/log"
+	"math/rand/v2"
+	"net"
 	"net/url"
-	"regexp"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -14,92 +18,215 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 )
 
+// defaultTimeout bounds every database call, unless the configuration or the
+// context of the call sets another timeout.
 const defaultTimeout = 3 * time.Second
 
+// DB is the primary pool, which the embedded *sqlx.DB methods use, along with
+// the replica pools GetContext, SelectContext and read-only transactions are
+// balanced across.
 type DB struct {
 	*sqlx.DB
+	replicas []*replica
+	balancer Balancer
+	next     atomic.Uint64
+	runner   runner
 }
 
+// New connects to the database, retrying up to options.Retries times with an
+// exponential backoff starting at options.RetryDelay and bounded by
+// options.MaxRetryDelay, so that the application can start alongside the
+// database. Connecting gives up once
+// options.ConnectTimeout has elapsed or ctx is done.
 func New(ctx context.Context, options Config) (*DB, error) {
 	logger := log.FromContext(ctx).Named("mysql")
-	logger.Info("connecting to mysql", "dsn", options.DSN())
 
-	db, err := sqlx.Connect(options.driver, options.DSN())
-	if err != nil {
-		return nil, err
+	if options.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.ConnectTimeout)
+		defer cancel()
 	}
 
-	db.SetMaxOpenConns(options.MaxOpenConns)
-	db.SetMaxIdleConns(options.MaxIdleConns)
-	db.SetConnMaxIdleTime(options.MaxIdleTime)
-	db.SetConnMaxLifetime(options.MaxLifetime)
+	for attempt := 1; ; attempt++ {
+		logger.Info("connecting to mysql", "dsn", options.Redacted(), "attempt", attempt)
+
+		primary, err := sqlx.ConnectContext(ctx, options.driver, options.DSN())
+		if err == nil {
+			options.configurePool(primary)
+
+			db := &DB{
+				DB:       primary,
+				balancer: options.ReplicaBalancer,
+				runner: runner{
+					timeout:    options.QueryTimeout,
+					logQueries: options.LogQueries,
+					slowQuery:  options.SlowQueryThreshold,
+				},
+			}
+			for _, addr := range options.Replicas {
+				db.replicas = append(db.replicas, openReplica(ctx, options, addr))
+			}
+
+			return db, nil
+		}
+
+		if attempt > options.Retries {
+			return nil, fmt.Errorf("mysql: unable to connect after %d attempts: %w", attempt, err)
+		}
+
+		wait := withJitter(options.retryDelay(attempt))
+		logger.Warn("unable to connect to mysql, retrying", "dsn", options.Redacted(), "attempt", attempt, "retryIn", wait.String(), "error", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("mysql: unable to connect after %d attempts: %w", attempt, errors.Join(ctx.Err(), err))
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay returns the delay before the attempt following the given one,
+// before jitter. A zero MaxRetryDelay leaves the delay unbounded.
+func (co *Config) retryDelay(attempt int) time.Duration {
+	delay := co.RetryDelay
+	for i := 1; i < attempt && (co.MaxRetryDelay <= 0 || delay < co.MaxRetryDelay); i++ {
+		delay *= 2
+	}
 
-	return &DB{db}, nil
+	if co.MaxRetryDelay > 0 {
+		return min(delay, co.MaxRetryDelay)
+	}
+	return delay
+}
+
+// Close closes the primary and the replica pools.
+func (db *DB) Close() error {
+	errs := []error{db.DB.Close()}
+	for _, r := range db.replicas {
+		errs = append(errs, r.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (co *Config) configurePool(db *sqlx.DB) {
+	db.SetMaxOpenConns(co.MaxOpenConns)
+	db.SetMaxIdleConns(co.MaxIdleConns)
+	db.SetConnMaxIdleTime(co.MaxIdleTime)
+	db.SetConnMaxLifetime(co.MaxLifetime)
+}
+
+// withJitter returns a random duration between d/2 and d, so that instances
+// started together do not retry in lockstep.
+func withJitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+
+	return d/2 + rand.N(d/2+1)
 }
 
 type (
-	// Config include common connection options
+	// Config include common connection options. The embedded driver
+	// configuration is the single source of the connection settings: address,
+	// credentials, database, TLS, collation, timeouts and extra params.
 	Config struct {
 		mysql.Config
-		Username string
-		Password string
-		Host     string
-		Port     int
-		Database string
-		driver   string
+		driver string
 
 		Retries        int
 		RetryDelay     time.Duration
+		MaxRetryDelay  time.Duration
 		ConnectTimeout time.Duration
 		MaxOpenConns   int
 		MaxIdleConns   int
 		MaxIdleTime    time.Duration
 		MaxLifetime    time.Duration
-	}
-)
 
-var dsnHostReplacer = regexp.MustCompile("//(.*)@(.*):(.*)/")
+		// Replicas are the "host:port" addresses of the read replicas,
+		// which share the rest of the connection settings.
+		Replicas        []string
+		ReplicaBalancer Balancer
 
-func (co *Config) DSN() string {
-	u := url.URL{
-		Host: fmt.Sprintf("%s:%d", co.Host, co.Port),
-		User: url.UserPassword(co.Username, co.Password),
-		Path: co.Database,
+		// QueryTimeout bounds every query and transaction, a zero timeout
+		// leaving them bounded by the deadline of their context only.
+		QueryTimeout time.Duration
+
+		// LogQueries logs every query, while queries taking at least
+		// SlowQueryThreshold are always logged. A zero threshold disables
+		// slow query logging.
+		LogQueries         bool
+		SlowQueryThreshold time.Duration
 	}
+)
 
-	params := u.Query()
-	params.Add("collation", "utf8_general_ci")
-	params.Add("parseTime", "true")
-	params.Add("loc", "UTC")
+// DSN returns the data source name passed to the driver.
+func (co *Config) DSN() string {
+	return co.Config.FormatDSN()
+}
 
-	u.RawQuery = params.Encode()
+// Redacted returns the DSN with the password masked, for logging.
+func (co *Config) Redacted() string {
+	redacted := co.Config.Clone()
+	if redacted.Passwd != "" {
+		redacted.Passwd = "xxxxx"
+	}
 
-	return dsnHostReplacer.ReplaceAllString(u.String(), "$1@tcp($2:$3)/")
+	return redacted.FormatDSN()
 }
 
+// NewConfig returns the configuration of a TCP connection to the schema, using
+// the utf8mb4 collation the database is created with. Times are parsed into
+// time.Time in UTC.
 func NewConfig(schemaName, host string, port int, username, password string) Config {
+	driverConfig := mysql.NewConfig()
+	driverConfig.User = username
+	driverConfig.Passwd = password
+	driverConfig.Net = "tcp"
+	driverConfig.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+	driverConfig.DBName = schemaName
+	driverConfig.Collation = "utf8mb4_unicode_ci"
+	driverConfig.ParseTime = true
+	driverConfig.Loc = time.UTC
+	driverConfig.Timeout = 5 * time.Second
+	driverConfig.ReadTimeout = 30 * time.Second
+	driverConfig.WriteTimeout = 30 * time.Second
+
 	return Config{
-		Config: mysql.Config{
-			User:   username,
-			Passwd: password,
-			Net:    "tcp",
-			Addr:   fmt.Sprintf("%s:%d", host, port),
-			DBName: schemaName,
-		},
-		Username: username,
-		Password: password,
-		Host:     host,
-		Port:     port,
-		Database: schemaName,
-		driver:   "mysql",
+		Config: *driverConfig,
+		driver: "mysql",
 
 		Retries:        3,
-		RetryDelay:     time.Second * 3,
+		RetryDelay:     3 * time.Second,
+		MaxRetryDelay:  30 * time.Second,
 		ConnectTimeout: time.Minute * 1,
 
 		MaxOpenConns: 25,
 		MaxIdleConns: 25,
 		MaxIdleTime:  5 * time.Minute,
 		MaxLifetime:  2 * time.Hour,
+
+		ReplicaBalancer: RoundRobin,
+
+		QueryTimeout:       defaultTimeout,
+		SlowQueryThreshold: 200 * time.Millisecond,
+	}
+}
+
+// ParseParams parses extra connection params in the query string form
+// "key=value&key=value", for example "sql_mode=TRADITIONAL&autocommit=true".
+func ParseParams(s string) (map[string]string, error) {
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: invalid params: %w", err)
+	}
+
+	params := make(map[string]string, len(values))
+	for key := range values {
+		params[key] = values.Get(key)
 	}
+
+	return params, nil
 }
@@ -0,0 +1,58 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		max      time.Duration
+		attempts []int
+		want     []time.Duration
+	}{
+		{
+			name:     "bounded",
+			max:      10 * time.Second,
+			attempts: []int{1, 2, 3, 4, 100},
+			want:     []time.Duration{3 * time.Second, 6 * time.Second, 10 * time.Second, 10 * time.Second, 10 * time.Second},
+		},
+		{
+			name:     "unbounded",
+			attempts: []int{1, 2, 3, 4},
+			want:     []time.Duration{3 * time.Second, 6 * time.Second, 12 * time.Second, 24 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			co := Config{RetryDelay: 3 * time.Second, MaxRetryDelay: tt.max}
+			for i, attempt := range tt.attempts {
+				if got := co.retryDelay(attempt); got != tt.want[i] {
+					t.Errorf("retryDelay(%d) = %v, want %v", attempt, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	for range 100 {
+		if got := withJitter(time.Second); got < time.Second/2 || got > time.Second {
+			t.Fatalf("withJitter(1s) = %v, want between 500ms and 1s", got)
+		}
+	}
+
+	if got := withJitter(0); got != 0 {
+		t.Errorf("withJitter(0) = %v, want 0", got)
+	}
+}
+
+func TestNewConfigRetryDefaults(t *testing.T) {
+	co := NewConfig("app", "localhost", 3306, "user", "secret")
+
+	if co.Retries != 3 || co.RetryDelay != 3*time.Second || co.MaxRetryDelay <= co.RetryDelay {
+		t.Errorf("retries = %d, delay = %v, max delay = %v", co.Retries, co.RetryDelay, co.MaxRetryDelay)
+	}
+}
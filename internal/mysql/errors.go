@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Domain errors returned in place of driver errors. The driver error is kept
+// in the chain, so errors.As still finds the *mysql.MySQLError.
+var (
+	ErrNotFound  = errors.New("mysql: record not found")
+	ErrDuplicate = errors.New("mysql: duplicate entry")
+	ErrConflict  = errors.New("mysql: conflict")
+	ErrTooLong   = errors.New("mysql: data too long")
+	ErrTimeout   = errors.New("mysql: timeout")
+
+	// ErrRecordNotFound is the former name of ErrNotFound.
+	ErrRecordNotFound = ErrNotFound
+
+	// ErrDuplicateEmail matches duplicate entries of the unique email index of
+	// the user table.
+	ErrDuplicateEmail error = &DuplicateError{Key: "uc_email"}
+)
+
+// Server error numbers translated to domain errors.
+const (
+	mysqlErrDuplicateEntry  = 1062
+	mysqlErrDataTooLong     = 1406
+	mysqlErrRowIsReferenced = 1451
+	mysqlErrNoReferencedRow = 1452
+	mysqlErrQueryTimeout    = 3024
+)
+
+// DuplicateError is a unique key violation. It matches ErrDuplicate, and
+// another DuplicateError with the same key.
+type DuplicateError struct {
+	Key string
+	err error
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("mysql: duplicate entry for key %q", e.Key)
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return e.err
+}
+
+func (e *DuplicateError) Is(target error) bool {
+	if target == ErrDuplicate {
+		return true
+	}
+
+	var other *DuplicateError
+	return errors.As(target, &other) && other.err == nil && other.Key == e.Key
+}
+
+var duplicateKey = regexp.MustCompile(`for key '([^']+)'`)
+
+// translateError returns the domain error matching err, wrapping err, or err
+// itself when there is none or it has already been translated.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, domainErr := range []error{ErrNotFound, ErrDuplicate, ErrConflict, ErrTooLong, ErrTimeout} {
+		if errors.Is(err, domainErr) {
+			return err
+		}
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+
+	switch mysqlErr.Number {
+	case mysqlErrDuplicateEntry:
+		key := ""
+		if m := duplicateKey.FindStringSubmatch(mysqlErr.Message); m != nil {
+			// MySQL 8 prefixes the key name with the table name.
+			key = m[1][strings.LastIndex(m[1], ".")+1:]
+		}
+		return &DuplicateError{Key: key, err: err}
+	case mysqlErrRowIsReferenced, mysqlErrNoReferencedRow, mysqlErrDeadlock:
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case mysqlErrDataTooLong:
+		return fmt.Errorf("%w: %w", ErrTooLong, err)
+	case mysqlErrLockWaitTimeout, mysqlErrQueryTimeout:
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	return err
+}
@@ -0,0 +1,85 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"nil", nil, nil},
+		{"no rows", sql.ErrNoRows, ErrNotFound},
+		{"deadline", context.DeadlineExceeded, ErrTimeout},
+		{"duplicate", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@b.c' for key 'user.uc_email'"}, ErrDuplicateEmail},
+		{"duplicate of another key", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'x' for key 'uc_name'"}, ErrDuplicate},
+		{"row referenced", &mysql.MySQLError{Number: 1451}, ErrConflict},
+		{"no referenced row", &mysql.MySQLError{Number: 1452}, ErrConflict},
+		{"deadlock", &mysql.MySQLError{Number: 1213}, ErrConflict},
+		{"too long", &mysql.MySQLError{Number: 1406}, ErrTooLong},
+		{"lock wait timeout", &mysql.MySQLError{Number: 1205}, ErrTimeout},
+		{"query timeout", &mysql.MySQLError{Number: 3024}, ErrTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translateError(tt.err)
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("translateError() = %v, want nil", got)
+				}
+				return
+			}
+			if !errors.Is(got, tt.want) {
+				t.Fatalf("translateError() = %v, want %v", got, tt.want)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("translateError() = %v, does not wrap %v", got, tt.err)
+			}
+		})
+	}
+}
+
+func TestTranslateErrorKeepsUnknownErrors(t *testing.T) {
+	for _, err := range []error{
+		errors.New("boom"),
+		&mysql.MySQLError{Number: 1064, Message: "syntax error"},
+	} {
+		if got := translateError(err); got != err {
+			t.Errorf("translateError(%v) = %v, want it unchanged", err, got)
+		}
+	}
+}
+
+func TestTranslateErrorIsIdempotent(t *testing.T) {
+	err := translateError(&mysql.MySQLError{Number: 1406})
+	if got := translateError(err); got != err {
+		t.Errorf("translateError() = %v, want %v unchanged", got, err)
+	}
+}
+
+func TestDuplicateErrorKey(t *testing.T) {
+	err := translateError(fmt.Errorf("insert: %w", &mysql.MySQLError{
+		Number:  1062,
+		Message: "Duplicate entry 'k' for key 'api_key.uc_prefix'",
+	}))
+
+	var dup *DuplicateError
+	if !errors.As(err, &dup) {
+		t.Fatalf("translateError() = %v, want a *DuplicateError", err)
+	}
+	if dup.Key != "uc_prefix" {
+		t.Errorf("Key = %q, want %q", dup.Key, "uc_prefix")
+	}
+	if errors.Is(err, ErrDuplicateEmail) {
+		t.Error("duplicate of uc_prefix matches ErrDuplicateEmail")
+	}
+}
@@ -0,0 +1,158 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/grocky/go-api-starter/internal/jobs"
+)
+
+// JobStore stores the job queue in the job table.
+type JobStore struct {
+	db *DB
+}
+
+// JobStore returns a jobs.Store backed by the database.
+func (db *DB) JobStore() *JobStore {
+	return &JobStore{db: db}
+}
+
+func (s *JobStore) Insert(ctx context.Context, job *jobs.Job) error {
+	query := `
+		INSERT INTO job (kind, args, priority, unique_key, max_attempts, run_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	uniqueKey := sql.NullString{String: job.UniqueKey, Valid: job.UniqueKey != ""}
+
+	result, err := s.db.ExecContext(ctx, query,
+		job.Kind, string(job.Args), job.Priority, uniqueKey, job.MaxAttempts, job.RunAt, job.CreatedAt)
+	if err != nil {
+		if errors.Is(err, ErrDuplicate) {
+			return jobs.ErrDuplicate
+		}
+		return err
+	}
+
+	job.ID, err = result.LastInsertId()
+	return err
+}
+
+// Claim locks the due jobs and those whose lease expired, skipping those
+// locked by concurrent claims, and leases them to owner. Jobs whose lease
+// expired on their last attempt are failed instead.
+func (s *JobStore) Claim(ctx context.Context, owner string, limit int, lease time.Duration) ([]*jobs.Job, error) {
+	var claimed []*jobs.Job
+
+	err := s.db.WithTx(ctx, nil, func(tx *Tx) error {
+		claimed = nil
+		now := time.Now().UTC()
+
+		query := `
+			UPDATE job
+			SET status = 'failed', failed_at = ?, last_error = ?, unique_key = NULL,
+				lease_owner = NULL, lease_expires_at = NULL
+			WHERE status = 'running' AND lease_expires_at <= ? AND attempts >= max_attempts`
+
+		if _, err := tx.ExecContext(ctx, query, now, jobs.ErrLeaseExpired.Error(), now); err != nil {
+			return err
+		}
+
+		query = `
+			SELECT id, kind, args, priority, COALESCE(unique_key, '') AS unique_key,
+				attempts, max_attempts, run_at, created_at
+			FROM job
+			WHERE (status = 'pending' AND run_at <= ?)
+				OR (status = 'running' AND lease_expires_at <= ? AND attempts < max_attempts)
+			ORDER BY priority DESC, run_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED`
+
+		if err := tx.SelectContext(ctx, &claimed, query, now, now, limit); err != nil {
+			return err
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(claimed))
+		for i, job := range claimed {
+			ids[i] = job.ID
+			job.Attempts++
+		}
+
+		query, args, err := sqlx.In(`
+			UPDATE job
+			SET status = 'running', attempts = attempts + 1, lease_owner = ?, lease_expires_at = ?
+			WHERE id IN (?)`, owner, now.Add(lease), ids)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// Heartbeat extends the lease. The expiry is stored in whole seconds and an
+// update leaving it unchanged affects no rows, so heartbeats must be more than
+// a second apart.
+func (s *JobStore) Heartbeat(ctx context.Context, id int64, owner string, lease time.Duration) error {
+	query := `
+		UPDATE job SET lease_expires_at = ?
+		WHERE id = ? AND status = 'running' AND lease_owner = ?`
+
+	return s.execLeased(ctx, query, time.Now().UTC().Add(lease), id, owner)
+}
+
+func (s *JobStore) Complete(ctx context.Context, id int64, owner string) error {
+	query := `DELETE FROM job WHERE id = ? AND status = 'running' AND lease_owner = ?`
+
+	return s.execLeased(ctx, query, id, owner)
+}
+
+func (s *JobStore) Retry(ctx context.Context, id int64, owner string, at time.Time, cause error) error {
+	query := `
+		UPDATE job
+		SET status = 'pending', run_at = ?, last_error = ?, lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = ? AND status = 'running' AND lease_owner = ?`
+
+	return s.execLeased(ctx, query, at.UTC(), cause.Error(), id, owner)
+}
+
+func (s *JobStore) Fail(ctx context.Context, id int64, owner string, cause error) error {
+	query := `
+		UPDATE job
+		SET status = 'failed', failed_at = ?, last_error = ?, unique_key = NULL,
+			lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = ? AND status = 'running' AND lease_owner = ?`
+
+	return s.execLeased(ctx, query, time.Now().UTC(), cause.Error(), id, owner)
+}
+
+// execLeased runs a query updating a job leased by its owner, the last
+// argument, and returns jobs.ErrLeaseLost when the job is not.
+func (s *JobStore) execLeased(ctx context.Context, query string, args ...any) error {
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return jobs.ErrLeaseLost
+	}
+
+	return nil
+}
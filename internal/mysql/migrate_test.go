@@ -0,0 +1,58 @@
+package mysql
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "empty",
+			script: " ;\n ; ",
+			want:   nil,
+		},
+		{
+			name:   "single without semicolon",
+			script: "CREATE TABLE a (id INT)",
+			want:   []string{"CREATE TABLE a (id INT)"},
+		},
+		{
+			name:   "several",
+			script: "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			want:   []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name:   "semicolons in quotes",
+			script: `INSERT INTO a VALUES ('x;y', "z;w", ` + "`c;d`" + `);`,
+			want:   []string{`INSERT INTO a VALUES ('x;y', "z;w", ` + "`c;d`" + `)`},
+		},
+		{
+			name:   "escaped quotes",
+			script: `INSERT INTO a VALUES ('it\'s; fine'); SELECT 1`,
+			want:   []string{`INSERT INTO a VALUES ('it\'s; fine')`, "SELECT 1"},
+		},
+		{
+			name:   "line comments",
+			script: "-- drop it; really\nDROP TABLE a; # done;\nSELECT 1;",
+			want:   []string{"DROP TABLE a", "SELECT 1"},
+		},
+		{
+			name:   "block comments",
+			script: "/* first; */ SELECT 1; /* multi\nline; */ SELECT 2;",
+			want:   []string{"SELECT 1", "SELECT 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !slices.Equal(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
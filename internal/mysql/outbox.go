@@ -0,0 +1,104 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/grocky/go-api-starter/internal/outbox"
+)
+
+// EnqueueOutboxMessage adds a message with the JSON encoding of payload to
+// the outbox. It is delivered once the transaction commits.
+func (tx *Tx) EnqueueOutboxMessage(ctx context.Context, topic string, payload any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	query := `
+		INSERT INTO outbox (topic, payload, available_at, created_at)
+		VALUES (?, ?, ?, ?)`
+
+	_, err = tx.ExecContext(ctx, query, topic, string(b), now, now)
+	return err
+}
+
+// OutboxStore stores the outbox in the outbox table.
+type OutboxStore struct {
+	db *DB
+}
+
+// OutboxStore returns an outbox.Store backed by the database.
+func (db *DB) OutboxStore() *OutboxStore {
+	return &OutboxStore{db: db}
+}
+
+// Claim locks the due messages, skipping those locked by concurrent claims,
+// and pushes their availability back by the lease.
+func (s *OutboxStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]*outbox.Message, error) {
+	var messages []*outbox.Message
+
+	err := s.db.WithTx(ctx, nil, func(tx *Tx) error {
+		messages = nil
+		now := time.Now().UTC()
+
+		query := `
+			SELECT id, topic, payload, attempts, created_at
+			FROM outbox
+			WHERE dead_at IS NULL AND available_at <= ?
+			ORDER BY available_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED`
+
+		if err := tx.SelectContext(ctx, &messages, query, now, limit); err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(messages))
+		for i, msg := range messages {
+			ids[i] = msg.ID
+			msg.Attempts++
+		}
+
+		query, args, err := sqlx.In(`
+			UPDATE outbox SET attempts = attempts + 1, available_at = ?
+			WHERE id IN (?)`, now.Add(lease), ids)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (s *OutboxStore) Delete(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM outbox WHERE id = ?`, id)
+	return err
+}
+
+func (s *OutboxStore) Retry(ctx context.Context, id int64, at time.Time, cause error) error {
+	query := `UPDATE outbox SET available_at = ?, last_error = ? WHERE id = ?`
+
+	_, err := s.db.ExecContext(ctx, query, at.UTC(), cause.Error(), id)
+	return err
+}
+
+func (s *OutboxStore) Bury(ctx context.Context, id int64, cause error) error {
+	query := `UPDATE outbox SET dead_at = ?, last_error = ?, payload = 'null' WHERE id = ?`
+
+	_, err := s.db.ExecContext(ctx, query, time.Now().UTC(), cause.Error(), id)
+	return err
+}
@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Permission codes known to the application. Permissions are created on first
+// grant, so adding a code here is enough to start using it.
+const (
+	PermissionDocumentsRead  = "documents:read"
+	PermissionDocumentsWrite = "documents:write"
+	PermissionsAdmin         = "permissions:admin"
+)
+
+// AllPermissions lists every known permission code.
+var AllPermissions = []string{
+	PermissionDocumentsRead,
+	PermissionDocumentsWrite,
+	PermissionsAdmin,
+}
+
+// DefaultPermissions are granted to new users.
+var DefaultPermissions = []string{
+	PermissionDocumentsRead,
+}
+
+type Permissions []string
+
+func (p Permissions) Include(code string) bool {
+	return slices.Contains(p, code)
+}
+
+func (db *DB) GetAllPermissionsForUser(ctx context.Context, userID string) (Permissions, error) {
+	query := `
+		SELECT permissions.code
+		FROM permissions
+		INNER JOIN users_permissions ON users_permissions.permission_id = permissions.id
+		WHERE users_permissions.user_id = ?
+		ORDER BY permissions.code`
+
+	permissions := Permissions{}
+	if err := db.SelectContext(ctx, &permissions, query, userID); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// AddPermissionsForUser grants the permissions to the user. Granting a
+// permission the user already has is not an error.
+func (db *DB) AddPermissionsForUser(ctx context.Context, userID string, codes ...string) error {
+	return addPermissionsForUser(ctx, db, userID, codes...)
+}
+
+// AddPermissionsForUser grants the permissions to the user within the
+// transaction.
+func (tx *Tx) AddPermissionsForUser(ctx context.Context, userID string, codes ...string) error {
+	return addPermissionsForUser(ctx, tx, userID, codes...)
+}
+
+func addPermissionsForUser(ctx context.Context, q Querier, userID string, codes ...string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("(?), ", len(codes)), ", ")
+	args := make([]any, len(codes))
+	for i, code := range codes {
+		args[i] = code
+	}
+
+	if _, err := q.ExecContext(ctx, `INSERT IGNORE INTO permissions (code) VALUES `+placeholders, args...); err != nil {
+		return err
+	}
+
+	query, args, err := sqlx.In(`
+		INSERT IGNORE INTO users_permissions (user_id, permission_id)
+		SELECT ?, permissions.id FROM permissions WHERE permissions.code IN (?)`, userID, codes)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, query, args...)
+	return err
+}
+
+// RemovePermissionsForUser revokes the permissions from the user.
+func (db *DB) RemovePermissionsForUser(ctx context.Context, userID string, codes ...string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(`
+		DELETE users_permissions
+		FROM users_permissions
+		INNER JOIN permissions ON permissions.id = users_permissions.permission_id
+		WHERE users_permissions.user_id = ? AND permissions.code IN (?)`, userID, codes)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, query, args...)
+	return err
+}
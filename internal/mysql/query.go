@@ -0,0 +1,326 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/grocky/go-api-starter/internal/log"
+)
+
+// The query methods below override those of the embedded *sqlx.DB and
+// *sqlx.Tx, so that every query is bounded by a timeout, logged and has its
+// errors translated. Reads made with the database, which are every query but
+// ExecContext, are balanced across the replicas. The methods without a context
+// run with context.Background(). The errors of the rows returned, including
+// those of the row of QueryRowxContext, are those of sqlx and are not
+// translated. Prepared statements are not covered and should not be used.
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	err := db.runner.run(ctx, query, args, func(ctx context.Context) (int64, error) {
+		var err error
+		if result, err = db.DB.ExecContext(ctx, query, args...); err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	})
+	if err == nil {
+		markWritten(ctx)
+	}
+	return result, translateError(err)
+}
+
+func (db *DB) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	return translateError(db.runner.run(ctx, query, args, func(ctx context.Context) (int64, error) {
+		return 1, db.read(ctx, func(q *sqlx.DB) error {
+			return q.GetContext(ctx, dest, query, args...)
+		})
+	}))
+}
+
+func (db *DB) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	return translateError(db.runner.run(ctx, query, args, func(ctx context.Context) (int64, error) {
+		err := db.read(ctx, func(q *sqlx.DB) error {
+			return q.SelectContext(ctx, dest, query, args...)
+		})
+		return rowsSelected(dest), err
+	}))
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := db.runner.query(ctx, query, args, func(ctx context.Context) error {
+		return db.read(ctx, func(q *sqlx.DB) error {
+			var err error
+			rows, err = q.QueryContext(ctx, query, args...)
+			return err
+		})
+	})
+	return rows, translateError(err)
+}
+
+func (db *DB) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	var rows *sqlx.Rows
+	err := db.runner.query(ctx, query, args, func(ctx context.Context) error {
+		return db.read(ctx, func(q *sqlx.DB) error {
+			var err error
+			rows, err = q.QueryxContext(ctx, query, args...)
+			return err
+		})
+	})
+	return rows, translateError(err)
+}
+
+func (db *DB) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	var row *sqlx.Row
+	_ = db.runner.query(ctx, query, args, func(ctx context.Context) error {
+		return db.read(ctx, func(q *sqlx.DB) error {
+			row = q.QueryRowxContext(ctx, query, args...)
+			return row.Err()
+		})
+	})
+	return row
+}
+
+func (db *DB) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	query, args, err := db.BindNamed(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return db.ExecContext(ctx, query, args...)
+}
+
+func (db *DB) NamedQueryContext(ctx context.Context, query string, arg any) (*sqlx.Rows, error) {
+	query, args, err := db.BindNamed(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryxContext(ctx, query, args...)
+}
+
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+func (db *DB) Get(dest any, query string, args ...any) error {
+	return db.GetContext(context.Background(), dest, query, args...)
+}
+
+func (db *DB) Select(dest any, query string, args ...any) error {
+	return db.SelectContext(context.Background(), dest, query, args...)
+}
+
+func (db *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+func (db *DB) Queryx(query string, args ...any) (*sqlx.Rows, error) {
+	return db.QueryxContext(context.Background(), query, args...)
+}
+
+func (db *DB) QueryRowx(query string, args ...any) *sqlx.Row {
+	return db.QueryRowxContext(context.Background(), query, args...)
+}
+
+func (db *DB) NamedExec(query string, arg any) (sql.Result, error) {
+	return db.NamedExecContext(context.Background(), query, arg)
+}
+
+func (db *DB) NamedQuery(query string, arg any) (*sqlx.Rows, error) {
+	return db.NamedQueryContext(context.Background(), query, arg)
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	err := tx.runner.run(ctx, query, args, func(ctx context.Context) (int64, error) {
+		var err error
+		if result, err = tx.Tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	})
+	return result, translateError(err)
+}
+
+func (tx *Tx) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	return translateError(tx.runner.run(ctx, query, args, func(ctx context.Context) (int64, error) {
+		return 1, tx.Tx.GetContext(ctx, dest, query, args...)
+	}))
+}
+
+func (tx *Tx) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	return translateError(tx.runner.run(ctx, query, args, func(ctx context.Context) (int64, error) {
+		err := tx.Tx.SelectContext(ctx, dest, query, args...)
+		return rowsSelected(dest), err
+	}))
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := tx.runner.query(ctx, query, args, func(ctx context.Context) error {
+		var err error
+		rows, err = tx.Tx.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, translateError(err)
+}
+
+func (tx *Tx) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	var rows *sqlx.Rows
+	err := tx.runner.query(ctx, query, args, func(ctx context.Context) error {
+		var err error
+		rows, err = tx.Tx.QueryxContext(ctx, query, args...)
+		return err
+	})
+	return rows, translateError(err)
+}
+
+func (tx *Tx) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	var row *sqlx.Row
+	_ = tx.runner.query(ctx, query, args, func(ctx context.Context) error {
+		row = tx.Tx.QueryRowxContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
+
+func (tx *Tx) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	query, args, err := tx.BindNamed(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return tx.ExecContext(ctx, query, args...)
+}
+
+func (tx *Tx) NamedQueryContext(ctx context.Context, query string, arg any) (*sqlx.Rows, error) {
+	query, args, err := tx.BindNamed(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return tx.QueryxContext(ctx, query, args...)
+}
+
+func (tx *Tx) Exec(query string, args ...any) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) Get(dest any, query string, args ...any) error {
+	return tx.GetContext(context.Background(), dest, query, args...)
+}
+
+func (tx *Tx) Select(dest any, query string, args ...any) error {
+	return tx.SelectContext(context.Background(), dest, query, args...)
+}
+
+func (tx *Tx) Query(query string, args ...any) (*sql.Rows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) Queryx(query string, args ...any) (*sqlx.Rows, error) {
+	return tx.QueryxContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) QueryRowx(query string, args ...any) *sqlx.Row {
+	return tx.QueryRowxContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) NamedExec(query string, arg any) (sql.Result, error) {
+	return tx.NamedExecContext(context.Background(), query, arg)
+}
+
+func (tx *Tx) NamedQuery(query string, arg any) (*sqlx.Rows, error) {
+	return tx.NamedQueryContext(context.Background(), query, arg)
+}
+
+// runner runs queries with a timeout, from the context when set with
+// WithQueryTimeout or the default timeout otherwise, and logs them through the
+// logger of their context, which carries the request ID of HTTP requests.
+// Every query is logged at info level when logQueries is set, while queries
+// taking at least slowQuery are logged at warn level with their arguments
+// redacted.
+type runner struct {
+	timeout    time.Duration
+	logQueries bool
+	slowQuery  time.Duration
+}
+
+// run runs fn, which returns the number of rows the query affected or
+// returned, and logs the query.
+func (r runner) run(ctx context.Context, query string, args []any, fn func(ctx context.Context) (int64, error)) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	rows, err := fn(ctx)
+
+	return r.log(ctx, query, args, time.Since(start), rows, contextError(ctx, err))
+}
+
+// query runs fn, which starts a query returning rows, and logs the query once
+// it has started. The rows are read after query returns, so the timeout is not
+// canceled and keeps bounding them until it elapses.
+func (r runner) query(ctx context.Context, query string, args []any, fn func(ctx context.Context) error) error {
+	ctx, _ = withTimeout(ctx, r.timeout)
+
+	start := time.Now()
+	err := fn(ctx)
+
+	return r.log(ctx, query, args, time.Since(start), -1, contextError(ctx, err))
+}
+
+// log logs the query when it is slow or every query is logged, along with the
+// number of rows when known, and returns err.
+func (r runner) log(ctx context.Context, query string, args []any, elapsed time.Duration, rows int64, err error) error {
+	slow := r.slowQuery > 0 && elapsed >= r.slowQuery
+	if !slow && !r.logQueries {
+		return err
+	}
+
+	attrs := []any{"query", compactQuery(query), "duration", elapsed.String()}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	} else if rows >= 0 {
+		attrs = append(attrs, "rows", rows)
+	}
+
+	logger := log.FromContext(ctx).Named("mysql")
+	if slow {
+		logger.Warn("slow query", append(attrs, "args", redactArgs(args))...)
+	} else {
+		logger.Info("query", attrs...)
+	}
+
+	return err
+}
+
+// compactQuery collapses the whitespace of the query onto a single line.
+func compactQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// redactArgs replaces the query arguments, which may be passwords, tokens or
+// personal data, with their types.
+func redactArgs(args []any) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = fmt.Sprintf("%T", arg)
+	}
+
+	return redacted
+}
+
+// rowsSelected returns the length of the slice dest points to.
+func rowsSelected(dest any) int64 {
+	v := reflect.ValueOf(dest)
+	if v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Slice {
+		return int64(v.Elem().Len())
+	}
+
+	return 0
+}
@@ -0,0 +1,94 @@
+package mysql
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// recoveryCodeCount is the number of recovery codes issued to a user.
+const recoveryCodeCount = 10
+
+// ReplaceRecoveryCodes generates a new set of one-time recovery codes for the
+// user, invalidating the previous set. Only the SHA-256 hashes are stored, the
+// plaintext codes returned must be shown to the user once.
+func (db *DB) ReplaceRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	var codes []string
+
+	err := db.WithTx(ctx, nil, func(tx *Tx) error {
+		var err error
+		codes, err = tx.ReplaceRecoveryCodes(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// ReplaceRecoveryCodes generates a new set of recovery codes for the user
+// within the transaction.
+func (tx *Tx) ReplaceRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := randomBase32(7)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code[:5] + "-" + code[5:10]
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("(?, ?), ", len(codes)), ", ")
+	args := make([]any, 0, 2*len(codes))
+	for _, code := range codes {
+		args = append(args, HashToken(code), userID)
+	}
+
+	if err := tx.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO recovery_code (hash, user_id) VALUES `+placeholders, args...); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// UseRecoveryCode marks the unused recovery code of the user as used.
+// ErrRecordNotFound is returned when there is no such code, including when a
+// concurrent request used it first.
+func (db *DB) UseRecoveryCode(ctx context.Context, userID, code string) error {
+	query := `
+		UPDATE recovery_code SET used_at = ?
+		WHERE hash = ? AND user_id = ? AND used_at IS NULL`
+
+	result, err := db.ExecContext(ctx, query, time.Now().UTC(), HashToken(strings.ToLower(strings.TrimSpace(code))), userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteRecoveryCodes removes every recovery code of the user.
+func (db *DB) DeleteRecoveryCodes(ctx context.Context, userID string) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM recovery_code WHERE user_id = ?`, userID)
+	return err
+}
+
+// DeleteRecoveryCodes removes every recovery code of the user within the
+// transaction.
+func (tx *Tx) DeleteRecoveryCodes(ctx context.Context, userID string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM recovery_code WHERE user_id = ?`, userID)
+	return err
+}
@@ -0,0 +1,149 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrRefreshTokenReused is returned when a refresh token which has already
+// been exchanged is presented again. Every token of its family is revoked, as
+// either the legitimate client or an attacker holds a stolen copy.
+var ErrRefreshTokenReused = errors.New("mysql: refresh token reused")
+
+// RefreshToken is a single-use token exchanged for a new access token and a
+// new refresh token. Tokens descending from the same login share a family, so
+// that the whole chain can be revoked at once. Only the SHA-256 hash of the
+// plaintext is stored.
+type RefreshToken struct {
+	Plaintext string       `db:"-" json:"token"`
+	Hash      []byte       `db:"hash" json:"-"`
+	UserID    string       `db:"user_id" json:"-"`
+	FamilyID  string       `db:"family_id" json:"-"`
+	Expiry    time.Time    `db:"expiry" json:"expiry"`
+	UsedAt    sql.NullTime `db:"used_at" json:"-"`
+}
+
+// NewRefreshToken generates and stores a refresh token starting a new family
+// for the user.
+func (db *DB) NewRefreshToken(ctx context.Context, userID string, ttl time.Duration) (*RefreshToken, error) {
+	token, err := generateRefreshToken(userID, uuid.NewString(), ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertRefreshToken(ctx, db, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// RotateRefreshToken marks the unexpired refresh token as used and returns a
+// new token of the same family, valid for ttl. ErrRecordNotFound is returned
+// when there is no such token, and ErrRefreshTokenReused when the token has
+// already been used, in which case its whole family has been revoked.
+func (db *DB) RotateRefreshToken(ctx context.Context, plaintext string, ttl time.Duration) (*RefreshToken, error) {
+	var next *RefreshToken
+	var reused bool
+
+	err := db.WithTx(ctx, nil, func(tx *Tx) error {
+		next, reused = nil, false
+
+		var current RefreshToken
+		query := `
+			SELECT hash, user_id, family_id, expiry, used_at
+			FROM refresh_token
+			WHERE hash = ?
+			FOR UPDATE`
+
+		if err := tx.GetContext(ctx, &current, query, HashToken(plaintext)); err != nil {
+			return err
+		}
+
+		// The family is revoked in the transaction, which must commit, so
+		// reuse is reported once it has.
+		if current.UsedAt.Valid {
+			reused = true
+			_, err := tx.ExecContext(ctx, `DELETE FROM refresh_token WHERE family_id = ?`, current.FamilyID)
+			return err
+		}
+
+		if !current.Expiry.After(time.Now()) {
+			return ErrRecordNotFound
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE refresh_token SET used_at = ? WHERE hash = ?`, time.Now().UTC(), current.Hash); err != nil {
+			return err
+		}
+
+		token, err := generateRefreshToken(current.UserID, current.FamilyID, ttl)
+		if err != nil {
+			return err
+		}
+
+		next = token
+		return insertRefreshToken(ctx, tx, next)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrRefreshTokenReused
+	}
+
+	return next, nil
+}
+
+// DeleteRefreshTokenFamily revokes the family of the refresh token, which
+// logs out the session it belongs to.
+func (db *DB) DeleteRefreshTokenFamily(ctx context.Context, plaintext string) error {
+	query := `
+		DELETE refresh_token
+		FROM refresh_token
+		INNER JOIN refresh_token AS presented ON presented.family_id = refresh_token.family_id
+		WHERE presented.hash = ?`
+
+	_, err := db.ExecContext(ctx, query, HashToken(plaintext))
+	return err
+}
+
+// DeleteAllRefreshTokensForUser revokes every refresh token of the user.
+func (db *DB) DeleteAllRefreshTokensForUser(ctx context.Context, userID string) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM refresh_token WHERE user_id = ?`, userID)
+	return err
+}
+
+// DeleteAllRefreshTokensForUser revokes every refresh token of the user within
+// the transaction.
+func (tx *Tx) DeleteAllRefreshTokensForUser(ctx context.Context, userID string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM refresh_token WHERE user_id = ?`, userID)
+	return err
+}
+
+func generateRefreshToken(userID, familyID string, ttl time.Duration) (*RefreshToken, error) {
+	token, err := GenerateToken(userID, ttl, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &RefreshToken{
+		Plaintext: token.Plaintext,
+		Hash:      token.Hash,
+		UserID:    userID,
+		FamilyID:  familyID,
+		Expiry:    token.Expiry,
+	}, nil
+}
+
+func insertRefreshToken(ctx context.Context, q Querier, token *RefreshToken) error {
+	query := `
+		INSERT INTO refresh_token (hash, user_id, family_id, expiry)
+		VALUES (?, ?, ?, ?)`
+
+	_, err := q.ExecContext(ctx, query, token.Hash, token.UserID, token.FamilyID, token.Expiry)
+	return err
+}
@@ -0,0 +1,197 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/grocky/go-api-starter/internal/log"
+)
+
+// Balancer chooses the replica serving a read.
+type Balancer string
+
+const (
+	// RoundRobin spreads reads evenly across the replicas.
+	RoundRobin Balancer = "round-robin"
+	// LeastConnections sends reads to the replica with the fewest
+	// connections in use.
+	LeastConnections Balancer = "least-connections"
+)
+
+// ParseBalancer returns the balancer named s.
+func ParseBalancer(s string) (Balancer, error) {
+	switch b := Balancer(s); b {
+	case RoundRobin, LeastConnections:
+		return b, nil
+	}
+
+	return "", fmt.Errorf("mysql: unknown balancer %q, expected %q or %q", s, RoundRobin, LeastConnections)
+}
+
+// replicaDownFor is how long a replica which failed to serve a read is left
+// out, its reads going to the primary in the meantime.
+const replicaDownFor = 10 * time.Second
+
+type replica struct {
+	*sqlx.DB
+	addr      string
+	downUntil atomic.Int64
+}
+
+func (r *replica) up(now time.Time) bool {
+	return now.UnixNano() >= r.downUntil.Load()
+}
+
+func (r *replica) markDown() {
+	r.downUntil.Store(time.Now().Add(replicaDownFor).UnixNano())
+}
+
+// openReplica opens the pool of the replica at addr. A replica which cannot be
+// reached does not prevent the application from starting: it is left out
+// until it recovers.
+func openReplica(ctx context.Context, options Config, addr string) *replica {
+	logger := log.FromContext(ctx).Named("mysql")
+
+	options.Config = *options.Config.Clone()
+	options.Addr = addr
+
+	logger.Info("connecting to mysql replica", "dsn", options.Redacted())
+
+	r := &replica{DB: sqlx.MustOpen(options.driver, options.DSN()), addr: addr}
+	options.configurePool(r.DB)
+
+	if err := r.PingContext(ctx); err != nil {
+		logger.Warn("unable to connect to mysql replica", "dsn", options.Redacted(), "error", err)
+		r.markDown()
+	}
+
+	return r
+}
+
+type contextKey string
+
+const (
+	contextKeyPrimary = contextKey("primary")
+	contextKeyWrites  = contextKey("writes")
+)
+
+// WithPrimary returns a context in which every read goes to the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKeyPrimary, true)
+}
+
+// WithReadYourWrites returns a context in which reads go to the primary once
+// a write has been made with it, so that they see the write despite the
+// replication lag. It is meant to scope a request.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKeyWrites, new(atomic.Bool))
+}
+
+func usePrimary(ctx context.Context) bool {
+	if forced, _ := ctx.Value(contextKeyPrimary).(bool); forced {
+		return true
+	}
+
+	wrote, _ := ctx.Value(contextKeyWrites).(*atomic.Bool)
+	return wrote != nil && wrote.Load()
+}
+
+func markWritten(ctx context.Context) {
+	if wrote, _ := ctx.Value(contextKeyWrites).(*atomic.Bool); wrote != nil {
+		wrote.Store(true)
+	}
+}
+
+// replica returns the replica to read from, or nil when reads must go to the
+// primary.
+func (db *DB) replica(ctx context.Context) *replica {
+	if len(db.replicas) == 0 || usePrimary(ctx) {
+		return nil
+	}
+
+	now := time.Now()
+
+	if db.balancer == LeastConnections {
+		var best *replica
+		for _, r := range db.replicas {
+			if r.up(now) && (best == nil || r.Stats().InUse < best.Stats().InUse) {
+				best = r
+			}
+		}
+		return best
+	}
+
+	start := db.next.Add(1)
+	for i := range uint64(len(db.replicas)) {
+		if r := db.replicas[(start+i)%uint64(len(db.replicas))]; r.up(now) {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// read runs fn on a replica, falling back to the primary when there is none
+// available or the replica cannot be reached.
+func (db *DB) read(ctx context.Context, fn func(q *sqlx.DB) error) error {
+	if r := db.replica(ctx); r != nil {
+		err := fn(r.DB)
+		if !isConnectionError(err) {
+			return err
+		}
+
+		log.FromContext(ctx).Named("mysql").Warn("mysql replica failed, reading from the primary", "replica", r.addr, "error", err)
+		r.markDown()
+	}
+
+	return fn(db.DB)
+}
+
+// beginTx starts a transaction, on a replica when it is read-only.
+func (db *DB) beginTx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	if opts != nil && opts.ReadOnly {
+		if r := db.replica(ctx); r != nil {
+			tx, err := r.BeginTxx(ctx, opts)
+			if !isConnectionError(err) {
+				return tx, err
+			}
+
+			log.FromContext(ctx).Named("mysql").Warn("mysql replica failed, starting the transaction on the primary", "replica", r.addr, "error", err)
+			r.markDown()
+		}
+	}
+
+	return db.BeginTxx(ctx, opts)
+}
+
+// ReplicaStats returns the statistics of the replica pools by address.
+func (db *DB) ReplicaStats() map[string]sql.DBStats {
+	stats := make(map[string]sql.DBStats, len(db.replicas))
+	for _, r := range db.replicas {
+		stats[r.addr] = r.Stats()
+	}
+
+	return stats
+}
+
+// isConnectionError reports whether err means the server could not be
+// reached, as opposed to the query failing.
+func isConnectionError(err error) bool {
+	// Context errors implement net.Error, but mean the caller gave up rather
+	// than the server being unreachable.
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.As(err, &netErr)
+}
@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"bad connection", driver.ErrBadConn, true},
+		{"invalid connection", mysql.ErrInvalidConn, true},
+		{"network", fmt.Errorf("dial: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), true},
+		{"deadline", context.DeadlineExceeded, false},
+		{"wrapped deadline", fmt.Errorf("%w: %w", ErrTimeout, context.DeadlineExceeded), false},
+		{"canceled", context.Canceled, false},
+		{"query", &mysql.MySQLError{Number: 1064}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectionError(tt.err); got != tt.want {
+				t.Errorf("isConnectionError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplicaRoundRobin(t *testing.T) {
+	a, b, c := &replica{addr: "a"}, &replica{addr: "b"}, &replica{addr: "c"}
+	db := &DB{replicas: []*replica{a, b, c}, balancer: RoundRobin}
+	ctx := context.Background()
+
+	pick := func(n int) []string {
+		var addrs []string
+		for range n {
+			addrs = append(addrs, db.replica(ctx).addr)
+		}
+		return addrs
+	}
+
+	if got, want := pick(3), []string{"b", "c", "a"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("replicas = %v, want %v", got, want)
+	}
+
+	// A replica down is skipped for the next one up.
+	b.markDown()
+	if got, want := pick(3), []string{"c", "c", "a"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("replicas with b down = %v, want %v", got, want)
+	}
+}
+
+func TestReplicaUsesPrimary(t *testing.T) {
+	down := &replica{addr: "down"}
+	down.markDown()
+
+	written := WithReadYourWrites(context.Background())
+	markWritten(written)
+
+	tests := []struct {
+		name string
+		db   *DB
+		ctx  context.Context
+	}{
+		{"no replicas", &DB{}, context.Background()},
+		{"replicas down", &DB{replicas: []*replica{down}}, context.Background()},
+		{"primary forced", &DB{replicas: []*replica{{addr: "up"}}}, WithPrimary(context.Background())},
+		{"after a write", &DB{replicas: []*replica{{addr: "up"}}}, written},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r := tt.db.replica(tt.ctx); r != nil {
+				t.Errorf("replica() = %q, want the primary", r.addr)
+			}
+		})
+	}
+}
+
+func TestReplicaBeforeWrite(t *testing.T) {
+	db := &DB{replicas: []*replica{{addr: "up"}}}
+	ctx := WithReadYourWrites(context.Background())
+
+	if r := db.replica(ctx); r == nil {
+		t.Error("replica() = primary before any write, want the replica")
+	}
+}
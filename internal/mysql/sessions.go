@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/grocky/go-api-starter/internal/session"
+)
+
+// SessionStore stores browser sessions in the session table.
+type SessionStore struct {
+	db *DB
+}
+
+// SessionStore returns a session.Store backed by the database.
+func (db *DB) SessionStore() *SessionStore {
+	return &SessionStore{db: db}
+}
+
+// Get reads from the primary, so that a session is neither missing right
+// after login nor still valid right after logout because of replication lag.
+func (s *SessionStore) Get(ctx context.Context, hash []byte) (*session.Session, error) {
+	ctx = WithPrimary(ctx)
+
+	query := `
+		SELECT hash, user_id, csrf_token, created_at, last_activity_at, expires_at
+		FROM session
+		WHERE hash = ? AND expires_at > ?`
+
+	var sess session.Session
+	if err := s.db.GetContext(ctx, &sess, query, hash, time.Now().UTC()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, session.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &sess, nil
+}
+
+func (s *SessionStore) Save(ctx context.Context, sess *session.Session) error {
+	query := `
+		INSERT INTO session (hash, user_id, csrf_token, created_at, last_activity_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE last_activity_at = VALUES(last_activity_at), expires_at = VALUES(expires_at)`
+
+	_, err := s.db.ExecContext(ctx, query, sess.Hash, sess.UserID, sess.CSRFToken, sess.CreatedAt, sess.LastActivityAt, sess.ExpiresAt)
+	return err
+}
+
+func (s *SessionStore) Delete(ctx context.Context, hash []byte) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM session WHERE hash = ?`, hash)
+	return err
+}
+
+func (s *SessionStore) DeleteAllForUser(ctx context.Context, userID string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM session WHERE user_id = ?`, userID)
+	return err
+}
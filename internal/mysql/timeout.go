@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const contextKeyTimeout = contextKey("timeout")
+
+// WithQueryTimeout returns a context in which database calls time out after d
+// instead of the default timeout. A zero d disables the timeout, leaving calls
+// bounded by the deadline of ctx only.
+func WithQueryTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, contextKeyTimeout, d)
+}
+
+// withTimeout returns a context bounded by the timeout set with
+// WithQueryTimeout, or d otherwise. The deadline of ctx still applies when it
+// is earlier.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if timeout, ok := ctx.Value(contextKeyTimeout).(time.Duration); ok {
+		d = timeout
+	}
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, d)
+}
+
+// contextError adds the error of ctx to err when ctx is done, since the
+// driver and database/sql do not always report why a call was interrupted.
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", ctx.Err(), err)
+}
@@ -0,0 +1,158 @@
+package mysql
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"time"
+)
+
+const (
+	ScopeActivation     = "activation"
+	ScopePasswordReset  = "password-reset"
+	ScopeAuthentication = "authentication"
+	ScopeTwoFactor      = "two-factor"
+)
+
+// Token is a single-use secret sent to a user. Only the SHA-256 hash of the
+// plaintext is stored.
+type Token struct {
+	Plaintext string    `db:"-" json:"token"`
+	Hash      []byte    `db:"hash" json:"-"`
+	UserID    string    `db:"user_id" json:"-"`
+	Expiry    time.Time `db:"expiry" json:"expiry"`
+	Scope     string    `db:"scope" json:"-"`
+}
+
+// GenerateToken returns a random token for the user which expires after ttl.
+func GenerateToken(userID string, ttl time.Duration, scope string) (*Token, error) {
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return nil, err
+	}
+
+	plaintext := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+
+	return &Token{
+		Plaintext: plaintext,
+		Hash:      HashToken(plaintext),
+		UserID:    userID,
+		Expiry:    time.Now().Add(ttl).UTC(),
+		Scope:     scope,
+	}, nil
+}
+
+// HashToken returns the SHA-256 hash of a token's plaintext.
+func HashToken(plaintext string) []byte {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hash[:]
+}
+
+// NewToken generates and stores a token for the user.
+func (db *DB) NewToken(ctx context.Context, userID string, ttl time.Duration, scope string) (*Token, error) {
+	return newToken(ctx, db, userID, ttl, scope)
+}
+
+// NewToken generates and stores a token for the user within the transaction.
+func (tx *Tx) NewToken(ctx context.Context, userID string, ttl time.Duration, scope string) (*Token, error) {
+	return newToken(ctx, tx, userID, ttl, scope)
+}
+
+func (db *DB) InsertToken(ctx context.Context, token *Token) error {
+	return insertToken(ctx, db, token)
+}
+
+func newToken(ctx context.Context, q Querier, userID string, ttl time.Duration, scope string) (*Token, error) {
+	token, err := GenerateToken(userID, ttl, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertToken(ctx, q, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func insertToken(ctx context.Context, q Querier, token *Token) error {
+	query := `
+		INSERT INTO token (hash, user_id, expiry, scope)
+		VALUES (?, ?, ?, ?)`
+
+	_, err := q.ExecContext(ctx, query, token.Hash, token.UserID, token.Expiry, token.Scope)
+	return err
+}
+
+// DeleteAllTokensForUser removes every token of the scope for the user, which
+// makes tokens single-use once the action they grant has been performed.
+func (db *DB) DeleteAllTokensForUser(ctx context.Context, scope, userID string) error {
+	return deleteAllTokensForUser(ctx, db, scope, userID)
+}
+
+// DeleteAllTokensForUser removes every token of the scope for the user within
+// the transaction.
+func (tx *Tx) DeleteAllTokensForUser(ctx context.Context, scope, userID string) error {
+	return deleteAllTokensForUser(ctx, tx, scope, userID)
+}
+
+func deleteAllTokensForUser(ctx context.Context, q Querier, scope, userID string) error {
+	query := `
+		DELETE FROM token
+		WHERE scope = ? AND user_id = ?`
+
+	_, err := q.ExecContext(ctx, query, scope, userID)
+	return err
+}
+
+// ConsumeToken deletes the unexpired token of the scope within the
+// transaction, so that it is redeemed once even by concurrent requests.
+// ErrRecordNotFound is returned when there is no such token.
+func (tx *Tx) ConsumeToken(ctx context.Context, scope, plaintext string) error {
+	query := `
+		DELETE FROM token
+		WHERE hash = ? AND scope = ? AND expiry > ?`
+
+	result, err := tx.ExecContext(ctx, query, HashToken(plaintext), scope, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// DeleteAllUserTokens removes every token of the user, whatever its scope.
+func (db *DB) DeleteAllUserTokens(ctx context.Context, userID string) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM token WHERE user_id = ?`, userID)
+	return err
+}
+
+// DeleteAllUserTokens removes every token of the user within the transaction.
+func (tx *Tx) DeleteAllUserTokens(ctx context.Context, userID string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM token WHERE user_id = ?`, userID)
+	return err
+}
+
+// GetUserForToken returns the user owning the unexpired token of the scope.
+// ErrRecordNotFound is returned when there is no such token. It reads from the
+// primary, so that a token revoked or just issued is seen as such.
+func (db *DB) GetUserForToken(ctx context.Context, scope, plaintext string) (*User, error) {
+	ctx = WithPrimary(ctx)
+
+	query := `
+		SELECT user.*
+		FROM user
+		INNER JOIN token ON user.id = token.user_id
+		WHERE token.hash = ? AND token.scope = ? AND token.expiry > ?`
+
+	return db.getUser(ctx, query, HashToken(plaintext), scope, time.Now().UTC())
+}
@@ -0,0 +1,156 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/grocky/go-api-starter/internal/log"
+)
+
+// Server error numbers after which the whole transaction can be retried.
+const (
+	mysqlErrLockWaitTimeout = 1205
+	mysqlErrDeadlock        = 1213
+)
+
+const (
+	txMaxAttempts = 3
+	txRetryDelay  = 20 * time.Millisecond
+)
+
+// Querier is implemented by both the database and transactions, so that
+// queries can be written once and run either way:
+//
+//	func insertThing(ctx context.Context, q Querier, thing *Thing) error
+type Querier interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+var (
+	_ Querier = (*DB)(nil)
+	_ Querier = (*Tx)(nil)
+)
+
+// Tx is a transaction started by WithTx.
+type Tx struct {
+	*sqlx.Tx
+	savepoints int
+	runner     runner
+}
+
+// WithTx runs fn in a transaction, which is committed when fn returns nil and
+// rolled back otherwise. The transaction is retried from the start with a
+// backoff when it fails with a deadlock or a lock wait timeout, so fn may be
+// called more than once and must not have side effects outside of tx.
+// Read-only transactions run on a replica. Each attempt is bounded by the
+// query timeout, as is each statement.
+func (db *DB) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *Tx) error) error {
+	delay := txRetryDelay
+
+	for attempt := 1; ; attempt++ {
+		err := db.withTx(ctx, opts, fn)
+		if err == nil || !isRetryable(err) || attempt == txMaxAttempts {
+			return err
+		}
+
+		log.FromContext(ctx).Named("mysql").Warn("retrying transaction", "attempt", attempt, "error", err)
+
+		timer := time.NewTimer(withJitter(delay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		delay *= 2
+	}
+}
+
+func (db *DB) withTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *Tx) error) (err error) {
+	// The transaction is rolled back by database/sql once txCtx is done.
+	txCtx, cancel := withTimeout(ctx, db.runner.timeout)
+	defer cancel()
+
+	defer func() {
+		err = translateError(contextError(txCtx, err))
+	}()
+
+	sqlxTx, err := db.beginTx(txCtx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = sqlxTx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = sqlxTx.Rollback()
+		}
+	}()
+
+	if err := fn(&Tx{Tx: sqlxTx, runner: db.runner}); err != nil {
+		return err
+	}
+
+	if err := sqlxTx.Commit(); err != nil {
+		return err
+	}
+
+	if opts == nil || !opts.ReadOnly {
+		markWritten(ctx)
+	}
+	return nil
+}
+
+// WithTx runs fn within a savepoint of the transaction. The changes made by
+// fn are rolled back to the savepoint when it returns an error, while the
+// enclosing transaction carries on.
+func (tx *Tx) WithTx(ctx context.Context, fn func(tx *Tx) error) (err error) {
+	tx.savepoints++
+	name := fmt.Sprintf("sp_%d", tx.savepoints)
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+		if err != nil {
+			if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+				err = errors.Join(err, rbErr)
+			}
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// isRetryable reports whether err aborted the transaction in a way which
+// running it again may resolve.
+func isRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrDeadlock || mysqlErr.Number == mysqlErrLockWaitTimeout
+	}
+
+	return false
+}
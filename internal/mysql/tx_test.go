@@ -0,0 +1,83 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"deadlock", &mysql.MySQLError{Number: 1213}, true},
+		{"lock wait timeout", &mysql.MySQLError{Number: 1205}, true},
+		{"translated deadlock", translateError(&mysql.MySQLError{Number: 1213}), true},
+		{"wrapped", fmt.Errorf("update: %w", &mysql.MySQLError{Number: 1205}), true},
+		{"duplicate", &mysql.MySQLError{Number: 1062}, false},
+		{"timeout", context.DeadlineExceeded, false},
+		{"other", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		d            time.Duration
+		wantDeadline bool
+	}{
+		{"default", context.Background(), time.Minute, true},
+		{"no default", context.Background(), 0, false},
+		{"overridden", WithQueryTimeout(context.Background(), time.Second), 0, true},
+		{"disabled", WithQueryTimeout(context.Background(), 0), time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := withTimeout(tt.ctx, tt.d)
+			defer cancel()
+
+			if _, ok := ctx.Deadline(); ok != tt.wantDeadline {
+				t.Errorf("deadline set = %v, want %v", ok, tt.wantDeadline)
+			}
+		})
+	}
+}
+
+func TestContextError(t *testing.T) {
+	errQuery := errors.New("invalid connection")
+
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if got := contextError(context.Background(), errQuery); got != errQuery {
+		t.Errorf("contextError(live ctx) = %v, want %v", got, errQuery)
+	}
+	if got := contextError(done, nil); got != nil {
+		t.Errorf("contextError(nil) = %v, want nil", got)
+	}
+
+	got := contextError(done, errQuery)
+	if !errors.Is(got, context.Canceled) || !errors.Is(got, errQuery) {
+		t.Errorf("contextError(done ctx) = %v, want both errors", got)
+	}
+	if again := contextError(done, got); again != got {
+		t.Errorf("contextError() wrapped the context error twice: %v", again)
+	}
+}
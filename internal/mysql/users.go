@@ -0,0 +1,194 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type User struct {
+	ID           string    `db:"id" json:"id"`
+	Email        string    `db:"email" json:"email"`
+	PasswordHash string    `db:"password_hash" json:"-"`
+	FirstName    string    `db:"first_name" json:"first_name"`
+	LastName     string    `db:"last_name" json:"last_name"`
+	Activated    bool      `db:"activated" json:"activated"`
+	DateJoined   time.Time `db:"date_joined" json:"date_joined"`
+	UpdatedAt    time.Time `db:"updated_at" json:"-"`
+
+	// TOTPSecret is set on enrollment, and TOTPEnabled once the user proved
+	// they can generate codes. TOTPLastStep is the time step of the last code
+	// accepted, which prevents codes from being replayed.
+	TOTPSecret   sql.NullString `db:"totp_secret" json:"-"`
+	TOTPEnabled  bool           `db:"totp_enabled" json:"two_factor_enabled"`
+	TOTPLastStep int64          `db:"totp_last_step" json:"-"`
+}
+
+// AnonymousUser represents an unauthenticated client.
+var AnonymousUser = &User{}
+
+func (u *User) IsAnonymous() bool {
+	return u == AnonymousUser
+}
+
+// InsertUser stores a new user. ErrDuplicateEmail is returned when another user
+// already has the email address.
+func (db *DB) InsertUser(ctx context.Context, user *User) error {
+	// The timestamps are read back from the primary, which has the row.
+	return insertUser(WithPrimary(ctx), db, user)
+}
+
+// InsertUser stores a new user within the transaction.
+func (tx *Tx) InsertUser(ctx context.Context, user *User) error {
+	return insertUser(ctx, tx, user)
+}
+
+func insertUser(ctx context.Context, q Querier, user *User) error {
+	query := `
+		INSERT INTO user (id, email, password_hash, first_name, last_name, activated)
+		VALUES (?, ?, ?, ?, ?, ?)`
+
+	_, err := q.ExecContext(ctx, query,
+		user.ID, user.Email, user.PasswordHash, user.FirstName, user.LastName, user.Activated)
+	if err != nil {
+		return err
+	}
+
+	return q.GetContext(ctx, user, `SELECT date_joined, updated_at FROM user WHERE id = ?`, user.ID)
+}
+
+func (db *DB) GetUser(ctx context.Context, id string) (*User, error) {
+	return db.getUser(ctx, `SELECT * FROM user WHERE id = ?`, id)
+}
+
+func (db *DB) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	return db.getUser(ctx, `SELECT * FROM user WHERE email = ?`, email)
+}
+
+func (db *DB) getUser(ctx context.Context, query string, args ...any) (*User, error) {
+	var user User
+
+	if err := db.GetContext(ctx, &user, query, args...); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// UpdateUser saves the profile fields of user: email address and names.
+// Credentials and account state have their own updates, so that a stale copy
+// of the user cannot undo a concurrent change to them. ErrDuplicateEmail is
+// returned when another user already has the email address.
+func (db *DB) UpdateUser(ctx context.Context, user *User) error {
+	query := `UPDATE user SET email = ?, first_name = ?, last_name = ? WHERE id = ?`
+
+	return updateUser(ctx, db, query, user.Email, user.FirstName, user.LastName, user.ID)
+}
+
+// SetPasswordHash replaces the password hash of the user.
+func (db *DB) SetPasswordHash(ctx context.Context, userID, hash string) error {
+	return setPasswordHash(ctx, db, userID, hash)
+}
+
+// SetPasswordHash replaces the password hash of the user within the
+// transaction.
+func (tx *Tx) SetPasswordHash(ctx context.Context, userID, hash string) error {
+	return setPasswordHash(ctx, tx, userID, hash)
+}
+
+func setPasswordHash(ctx context.Context, q Querier, userID, hash string) error {
+	return updateUser(ctx, q, `UPDATE user SET password_hash = ? WHERE id = ?`, hash, userID)
+}
+
+// RehashPassword replaces the password hash of the user with newHash, unless
+// it is no longer oldHash because the password changed in the meantime, in
+// which case nothing is done.
+func (db *DB) RehashPassword(ctx context.Context, userID, oldHash, newHash string) error {
+	query := `UPDATE user SET password_hash = ? WHERE id = ? AND password_hash = ?`
+
+	_, err := db.ExecContext(ctx, query, newHash, userID, oldHash)
+	return err
+}
+
+// SetActivated marks the user as activated within the transaction.
+func (tx *Tx) SetActivated(ctx context.Context, userID string) error {
+	return updateUser(ctx, tx, `UPDATE user SET activated = TRUE WHERE id = ?`, userID)
+}
+
+// SetTOTPSecret enrolls the user with a new TOTP secret. ErrConflict is
+// returned when two-factor authentication was enabled in the meantime.
+func (db *DB) SetTOTPSecret(ctx context.Context, userID, secret string) error {
+	query := `
+		UPDATE user SET totp_secret = ?, totp_last_step = 0
+		WHERE id = ? AND totp_enabled = FALSE`
+
+	return updateUserIf(ctx, db, query, secret, userID)
+}
+
+// EnableTOTP enables two-factor authentication with the enrolled secret
+// within the transaction, recording step as the last one used. ErrConflict is
+// returned when the user enrolled another secret, enabled it or used the step
+// in the meantime.
+func (tx *Tx) EnableTOTP(ctx context.Context, userID, secret string, step int64) error {
+	query := `
+		UPDATE user SET totp_enabled = TRUE, totp_last_step = ?
+		WHERE id = ? AND totp_secret = ? AND totp_enabled = FALSE AND totp_last_step < ?`
+
+	return updateUserIf(ctx, tx, query, step, userID, secret, step)
+}
+
+// DisableTOTP turns two-factor authentication off and forgets the secret
+// within the transaction.
+func (tx *Tx) DisableTOTP(ctx context.Context, userID string) error {
+	query := `UPDATE user SET totp_enabled = FALSE, totp_secret = NULL, totp_last_step = 0 WHERE id = ?`
+
+	return updateUser(ctx, tx, query, userID)
+}
+
+// UseTOTPStep records step as the last TOTP time step used by the user, which
+// must be later than the previous one. ErrConflict is returned otherwise, in
+// particular when a concurrent request used a code of the same step first.
+func (db *DB) UseTOTPStep(ctx context.Context, userID string, step int64) error {
+	query := `UPDATE user SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?`
+
+	return updateUserIf(ctx, db, query, step, userID, step)
+}
+
+// updateUserIf runs a conditional update of a user, returning ErrConflict
+// when its condition does not hold.
+func updateUserIf(ctx context.Context, q Querier, query string, args ...any) error {
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrConflict
+	}
+
+	return nil
+}
+
+// updateUser runs an update of the user whose ID is the last argument.
+// ErrRecordNotFound is returned when there is no such user.
+func updateUser(ctx context.Context, q Querier, query string, args ...any) error {
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	// No row is affected either when the user does not exist or when nothing
+	// changed, which only the former makes an error.
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		var id string
+		if err := q.GetContext(ctx, &id, `SELECT id FROM user WHERE id = ?`, args[len(args)-1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+// Package outbox delivers messages recorded in the same transaction as the
+// change they announce, so that they are neither lost when the process stops
+// after the commit nor sent when the transaction rolls back.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grocky/go-api-starter/internal/log"
+)
+
+// Message is a message waiting in the outbox. Attempts counts the deliveries
+// started, including the current one once claimed.
+type Message struct {
+	ID        int64           `db:"id"`
+	Topic     string          `db:"topic"`
+	Payload   json.RawMessage `db:"payload"`
+	Attempts  int             `db:"attempts"`
+	CreatedAt time.Time       `db:"created_at"`
+}
+
+// Store persists the outbox.
+type Store interface {
+	// Claim returns up to limit messages which are due, hiding them from
+	// other claims for the lease and counting an attempt for each. A message
+	// whose delivery does not complete within the lease is claimed again.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*Message, error)
+	// Delete removes a delivered message.
+	Delete(ctx context.Context, id int64) error
+	// Retry records the failed delivery and makes the message due again at.
+	Retry(ctx context.Context, id int64, at time.Time, cause error) error
+	// Bury records the failed delivery and sets the message aside for good,
+	// leaving it in the store for inspection. The payload is dropped, as it
+	// may hold secrets such as tokens which must not outlive the delivery.
+	Bury(ctx context.Context, id int64, cause error) error
+}
+
+// Handler delivers the payload of messages of a topic.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Dispatcher delivers the messages of the outbox to the handlers of their
+// topic. Delivery is at least once: a handler may see a message again when
+// the process stops before the delivery is recorded.
+type Dispatcher struct {
+	Store Store
+
+	// PollInterval is the time between two claims when the outbox is
+	// drained, unless Notify is called.
+	PollInterval time.Duration
+	BatchSize    int
+	// Lease bounds the delivery of each batch. Messages of the batch which
+	// are not delivered in time are claimed again once it expires.
+	Lease time.Duration
+
+	// MaxAttempts is the number of deliveries before a message is buried.
+	// Failed deliveries are retried after RetryDelay, doubling after each
+	// attempt up to MaxRetryDelay.
+	MaxAttempts   int
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+
+	handlers map[string]Handler
+	notify   chan struct{}
+}
+
+// NewDispatcher returns a dispatcher of the messages of the store, which
+// retries a failed delivery for about six hours before burying it.
+func NewDispatcher(store Store) *Dispatcher {
+	return &Dispatcher{
+		Store:         store,
+		PollInterval:  5 * time.Second,
+		BatchSize:     10,
+		Lease:         time.Minute,
+		MaxAttempts:   12,
+		RetryDelay:    10 * time.Second,
+		MaxRetryDelay: 6 * time.Hour,
+		handlers:      make(map[string]Handler),
+		notify:        make(chan struct{}, 1),
+	}
+}
+
+// Handle registers the handler of the topic.
+func (d *Dispatcher) Handle(topic string, h Handler) {
+	d.handlers[topic] = h
+}
+
+// Notify wakes the dispatcher up, to deliver messages just committed without
+// waiting for the next poll.
+func (d *Dispatcher) Notify() {
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers messages until ctx is done. The delivery in progress is
+// completed rather than interrupted.
+func (d *Dispatcher) Run(ctx context.Context) {
+	logger := log.FromContext(ctx).Named("outbox")
+
+	for {
+		n, err := d.dispatch(ctx)
+		if err != nil {
+			logger.Error("unable to dispatch outbox messages", "error", err)
+		}
+
+		if err == nil && n == d.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		timer := time.NewTimer(d.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-d.notify:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatch claims and delivers a batch of messages, returning how many were
+// claimed.
+func (d *Dispatcher) dispatch(ctx context.Context) (int, error) {
+	deadline := time.Now().Add(d.Lease)
+
+	messages, err := d.Store.Claim(ctx, d.BatchSize, d.Lease)
+	if err != nil {
+		// A claim interrupted by shutting down is not a failure.
+		if ctx.Err() != nil {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	// Claimed messages are delivered and recorded even when ctx is done, so
+	// that shutting down does not wait for the lease to expire.
+	ctx = context.WithoutCancel(ctx)
+
+	var errs []error
+	for _, msg := range messages {
+		if !time.Now().Before(deadline) {
+			break
+		}
+		errs = append(errs, d.deliver(ctx, msg, deadline))
+	}
+
+	return len(messages), errors.Join(errs...)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, msg *Message, deadline time.Time) error {
+	logger := log.FromContext(ctx).Named("outbox").With("messageId", msg.ID, "topic", msg.Topic, "attempt", msg.Attempts)
+
+	err := d.handle(ctx, msg, deadline)
+	if err == nil {
+		return d.Store.Delete(ctx, msg.ID)
+	}
+
+	if msg.Attempts >= d.MaxAttempts {
+		logger.Error("burying outbox message", "error", err)
+		return d.Store.Bury(ctx, msg.ID, err)
+	}
+
+	at := time.Now().Add(d.retryDelay(msg.Attempts))
+	logger.Warn("outbox message delivery failed, retrying", "retryAt", at, "error", err)
+	return d.Store.Retry(ctx, msg.ID, at, err)
+}
+
+func (d *Dispatcher) handle(ctx context.Context, msg *Message, deadline time.Time) (err error) {
+	h, ok := d.handlers[msg.Topic]
+	if !ok {
+		return fmt.Errorf("outbox: no handler for topic %q", msg.Topic)
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("outbox: handler panic: %v", p)
+		}
+	}()
+
+	return h(ctx, msg.Payload)
+}
+
+// retryDelay returns the delay before the attempt following the given one.
+func (d *Dispatcher) retryDelay(attempts int) time.Duration {
+	delay := d.RetryDelay
+	for i := 1; i < attempts && delay < d.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, d.MaxRetryDelay)
+}
@@ -0,0 +1,197 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/grocky/go-api-starter/internal/log"
+)
+
+// quiet returns a context whose logger discards the records.
+func quiet() context.Context {
+	return log.WithLogger(context.Background(), log.New(io.Discard, slog.LevelError))
+}
+
+// memoryStore records the outcome of the deliveries of its messages.
+type memoryStore struct {
+	messages []*Message
+	deleted  []int64
+	retried  map[int64]error
+	buried   map[int64]error
+}
+
+func newMemoryStore(messages ...*Message) *memoryStore {
+	return &memoryStore{
+		messages: messages,
+		retried:  map[int64]error{},
+		buried:   map[int64]error{},
+	}
+}
+
+func (s *memoryStore) Claim(_ context.Context, limit int, _ time.Duration) ([]*Message, error) {
+	n := min(limit, len(s.messages))
+	claimed := s.messages[:n]
+	s.messages = s.messages[n:]
+
+	for _, msg := range claimed {
+		msg.Attempts++
+	}
+
+	return claimed, nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, id int64) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func (s *memoryStore) Retry(_ context.Context, id int64, _ time.Time, cause error) error {
+	s.retried[id] = cause
+	return nil
+}
+
+func (s *memoryStore) Bury(_ context.Context, id int64, cause error) error {
+	s.buried[id] = cause
+	return nil
+}
+
+func TestDispatcherDeliver(t *testing.T) {
+	errDelivery := errors.New("delivery failed")
+
+	tests := []struct {
+		name     string
+		topic    string
+		attempts int
+		handler  Handler
+		want     string
+	}{
+		{
+			name:    "delivered",
+			topic:   "ok",
+			handler: func(context.Context, json.RawMessage) error { return nil },
+			want:    "deleted",
+		},
+		{
+			name:    "failed",
+			topic:   "ok",
+			handler: func(context.Context, json.RawMessage) error { return errDelivery },
+			want:    "retried",
+		},
+		{
+			name:     "failed for the last time",
+			topic:    "ok",
+			attempts: 2,
+			handler:  func(context.Context, json.RawMessage) error { return errDelivery },
+			want:     "buried",
+		},
+		{
+			name:    "panicked",
+			topic:   "ok",
+			handler: func(context.Context, json.RawMessage) error { panic("boom") },
+			want:    "retried",
+		},
+		{
+			name:  "unknown topic",
+			topic: "unknown",
+			want:  "retried",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore(&Message{ID: 1, Topic: tt.topic, Attempts: tt.attempts})
+
+			d := NewDispatcher(store)
+			d.MaxAttempts = 3
+			if tt.handler != nil {
+				d.Handle("ok", tt.handler)
+			}
+
+			n, err := d.dispatch(quiet())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 1 {
+				t.Fatalf("dispatch() = %d messages, want 1", n)
+			}
+
+			var got string
+			switch {
+			case len(store.deleted) == 1:
+				got = "deleted"
+			case len(store.retried) == 1:
+				got = "retried"
+			case len(store.buried) == 1:
+				got = "buried"
+			}
+			if got != tt.want {
+				t.Errorf("message %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// canceledStore fails to claim messages once its context is done, as the
+// database does.
+type canceledStore struct{ memoryStore }
+
+func (s *canceledStore) Claim(ctx context.Context, _ int, _ time.Duration) ([]*Message, error) {
+	return nil, ctx.Err()
+}
+
+func TestDispatcherRunCanceled(t *testing.T) {
+	var buf bytes.Buffer
+	ctx, cancel := context.WithCancel(log.WithLogger(context.Background(), log.New(&buf, slog.LevelDebug)))
+	cancel()
+
+	NewDispatcher(&canceledStore{}).Run(ctx)
+
+	if buf.Len() > 0 {
+		t.Errorf("Run() logged %q when canceled, want nothing", buf.String())
+	}
+}
+
+func TestDispatcherHandlerDeadline(t *testing.T) {
+	store := newMemoryStore(&Message{ID: 1, Topic: "slow"})
+
+	d := NewDispatcher(store)
+	d.Lease = 10 * time.Millisecond
+	d.Handle("slow", func(ctx context.Context, _ json.RawMessage) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if _, err := d.dispatch(quiet()); err != nil {
+		t.Fatal(err)
+	}
+	if cause := store.retried[1]; !errors.Is(cause, context.DeadlineExceeded) {
+		t.Errorf("retry cause = %v, want context.DeadlineExceeded", cause)
+	}
+}
+
+func TestDispatcherRetryDelay(t *testing.T) {
+	d := &Dispatcher{RetryDelay: 10 * time.Second, MaxRetryDelay: time.Minute}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{4, time.Minute},
+		{100, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := d.retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package password
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestArgon2idRejectsInvalidHashes(t *testing.T) {
+	salt := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	key := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"empty salt and key", "$argon2id$v=19$m=64,t=1,p=1$$"},
+		{"empty salt", "$argon2id$v=19$m=64,t=1,p=1$$" + key},
+		{"empty key", "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$"},
+		{"short salt", "$argon2id$v=19$m=64,t=1,p=1$YWJj$" + key},
+		{"zero iterations", "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key},
+		{"zero memory", "$argon2id$v=19$m=0,t=1,p=1$" + salt + "$" + key},
+		{"zero parallelism", "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key},
+		{"memory below 8 per lane", "$argon2id$v=19$m=15,t=1,p=2$" + salt + "$" + key},
+		{"huge memory", "$argon2id$v=19$m=4294967295,t=1,p=1$" + salt + "$" + key},
+		{"huge iterations", "$argon2id$v=19$m=64,t=4294967295,p=1$" + salt + "$" + key},
+		{"huge parallelism", "$argon2id$v=19$m=64,t=1,p=255$" + salt + "$" + key},
+		{"long key", "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$" + base64.RawStdEncoding.EncodeToString(make([]byte, 65))},
+		{"wrong version", "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key},
+		{"wrong algorithm", "$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + key},
+		{"bad base64", "$argon2id$v=19$m=64,t=1,p=1$!!!$" + key},
+		{"missing field", "$argon2id$v=19$m=64,t=1,p=1$" + salt},
+	}
+
+	a := NewArgon2id(DefaultArgon2idParams)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := a.Matches("", tt.hash)
+			if ok {
+				t.Fatal("Matches() = true, want false")
+			}
+			if !errors.Is(err, ErrInvalidHash) {
+				t.Fatalf("Matches() error = %v, want ErrInvalidHash", err)
+			}
+			if !a.NeedsRehash(tt.hash) {
+				t.Error("NeedsRehash() = false, want true")
+			}
+		})
+	}
+}
+
+func TestArgon2idRoundTrip(t *testing.T) {
+	params := Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	a := NewArgon2id(params)
+
+	hash, err := a.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") || !a.Recognizes(hash) {
+		t.Fatalf("Hash() = %q", hash)
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"correct horse battery staple", true},
+		{"correct horse battery stapler", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		ok, err := a.Matches(tt.password, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.password, ok, tt.want)
+		}
+	}
+
+	if a.NeedsRehash(hash) {
+		t.Error("NeedsRehash() = true for the current parameters")
+	}
+	if !NewArgon2id(DefaultArgon2idParams).NeedsRehash(hash) {
+		t.Error("NeedsRehash() = false for other parameters")
+	}
+}
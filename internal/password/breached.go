@@ -0,0 +1,214 @@
+package password
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// scanBlockSize is the size below which a range of the sorted file is scanned
+// linearly instead of being bisected further.
+const scanBlockSize = 16 * 1024
+
+// BreachChecker reports how many times a password appears in a breach corpus.
+type BreachChecker interface {
+	Breached(plaintextPassword string) (int, error)
+}
+
+// BreachCheckCloser is a BreachChecker holding files which Close releases.
+type BreachCheckCloser interface {
+	BreachChecker
+	io.Closer
+}
+
+// OpenBreached opens a Have I Been Pwned SHA-1 dataset stored at path. The
+// password is never sent anywhere, only its SHA-1 hash is looked up locally.
+//
+// path may be either of the layouts produced by the PwnedPasswordsDownloader:
+//
+//   - a single file of "HASH:COUNT" lines ordered by hash, which is searched
+//     with a binary search over byte offsets so that it is never loaded into
+//     memory.
+//   - a directory of prefix range files, named after the first five
+//     characters of the hash with an optional .txt extension, each holding
+//     "SUFFIX:COUNT" lines. This is the same k-anonymity layout as the range
+//     API.
+//
+// The dataset must be closed once no longer used.
+func OpenBreached(path string) (BreachCheckCloser, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return &BreachedRanges{dir: path}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BreachedFile{f: f, size: info.Size()}, nil
+}
+
+// sha1Hex returns the upper case hex encoded SHA-1 hash of s, as used by the
+// Have I Been Pwned datasets.
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// parseCount parses the "HASH:COUNT" line format, returning the hash part and
+// the count.
+func parseCount(line []byte) (string, int, error) {
+	hash, count, found := bytes.Cut(bytes.TrimSpace(line), []byte(":"))
+	if !found {
+		return "", 0, fmt.Errorf("password: malformed breach line %q", line)
+	}
+
+	n, err := strconv.Atoi(string(count))
+	if err != nil {
+		return "", 0, fmt.Errorf("password: malformed breach count %q", line)
+	}
+
+	return strings.ToUpper(string(hash)), n, nil
+}
+
+// BreachedFile looks up hashes in a single file ordered by hash. It is safe for
+// concurrent use as it only reads with ReadAt.
+type BreachedFile struct {
+	f    *os.File
+	size int64
+}
+
+func (b *BreachedFile) Breached(plaintextPassword string) (int, error) {
+	target := sha1Hex(plaintextPassword)
+
+	// Invariant: lo is the start of a line whose hash is <= target, or 0, and
+	// hi is the start of a line whose hash is > target, or the end of file.
+	lo, hi := int64(0), b.size
+
+	for hi-lo > scanBlockSize {
+		mid := lo + (hi-lo)/2
+
+		start, line, err := b.lineAfter(mid)
+		if err != nil {
+			return 0, err
+		}
+		if start >= hi {
+			break
+		}
+
+		hash, _, err := parseCount(line)
+		if err != nil {
+			return 0, err
+		}
+
+		if hash <= target {
+			lo = start
+		} else {
+			hi = start
+		}
+	}
+
+	block := make([]byte, hi-lo)
+	if _, err := b.f.ReadAt(block, lo); err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	for _, line := range bytes.Split(block, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		hash, count, err := parseCount(line)
+		if err != nil {
+			return 0, err
+		}
+		if hash == target {
+			return count, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// lineAfter returns the offset and content of the first line starting after
+// pos. The offset is the file size when there is no such line.
+func (b *BreachedFile) lineAfter(pos int64) (int64, []byte, error) {
+	r := bufio.NewReader(io.NewSectionReader(b.f, pos, b.size-pos))
+
+	skipped, err := r.ReadBytes('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return b.size, nil, nil
+		}
+		return 0, nil, err
+	}
+
+	line, err := r.ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, nil, err
+	}
+
+	return pos + int64(len(skipped)), line, nil
+}
+
+func (b *BreachedFile) Close() error {
+	return b.f.Close()
+}
+
+// BreachedRanges looks up hashes in a directory of prefix range files.
+type BreachedRanges struct {
+	dir string
+}
+
+// Close does nothing, as range files are opened for each lookup.
+func (b *BreachedRanges) Close() error {
+	return nil
+}
+
+func (b *BreachedRanges) Breached(plaintextPassword string) (int, error) {
+	hash := sha1Hex(plaintextPassword)
+	prefix, suffix := hash[:5], hash[5:]
+
+	f, err := os.Open(filepath.Join(b.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		f, err = os.Open(filepath.Join(b.dir, prefix))
+	}
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		lineSuffix, count, err := parseCount(scanner.Bytes())
+		if err != nil {
+			return 0, err
+		}
+		if lineSuffix == suffix {
+			return count, nil
+		}
+	}
+
+	return 0, scanner.Err()
+}
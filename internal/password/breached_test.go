@@ -0,0 +1,119 @@
+package password
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeBreachedFile writes a sorted dataset holding the passwords, each with
+// its index plus one as count, padded with enough filler hashes for the
+// lookups to bisect.
+func writeBreachedFile(t *testing.T, passwords []string, filler int) string {
+	t.Helper()
+
+	var lines []string
+	for i, p := range passwords {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(p), i+1))
+	}
+	for i := range filler {
+		lines = append(lines, fmt.Sprintf("%s:1", sha1Hex(fmt.Sprintf("filler-%d", i))))
+	}
+	slices.Sort(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestBreachedFile(t *testing.T) {
+	passwords := []string{"password", "123456", "correct horse battery staple"}
+
+	tests := []struct {
+		name   string
+		filler int
+	}{
+		{"empty", 0},
+		{"single block", 10},
+		{"bisected", 5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path string
+			if tt.filler == 0 {
+				path = filepath.Join(t.TempDir(), "pwned.txt")
+				if err := os.WriteFile(path, nil, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				path = writeBreachedFile(t, passwords, tt.filler)
+			}
+
+			b, err := OpenBreached(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer b.Close()
+
+			for i, p := range passwords {
+				want := i + 1
+				if tt.filler == 0 {
+					want = 0
+				}
+
+				got, err := b.Breached(p)
+				if err != nil {
+					t.Fatalf("Breached(%q) error = %v", p, err)
+				}
+				if got != want {
+					t.Errorf("Breached(%q) = %d, want %d", p, got, want)
+				}
+			}
+
+			if got, err := b.Breached("never breached"); err != nil || got != 0 {
+				t.Errorf("Breached(never breached) = %d, %v, want 0", got, err)
+			}
+		})
+	}
+}
+
+func TestBreachedRanges(t *testing.T) {
+	dir := t.TempDir()
+
+	hash := sha1Hex("password")
+	content := fmt.Sprintf("0000000000000000000000000000000000A:3\r\n%s:42\r\n", hash[5:])
+	if err := os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := OpenBreached(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"password", 42},
+		{"never breached", 0},
+	}
+
+	for _, tt := range tests {
+		got, err := b.Breached(tt.password)
+		if err != nil {
+			t.Fatalf("Breached(%q) error = %v", tt.password, err)
+		}
+		if got != tt.want {
+			t.Errorf("Breached(%q) = %d, want %d", tt.password, got, tt.want)
+		}
+	}
+}
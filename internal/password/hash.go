@@ -2,29 +2,100 @@ package password
 
 import (
 	"errors"
+)
 
-	"golang.org/x/crypto/bcrypt"
+var (
+	ErrInvalidHash      = errors.New("password: invalid hash")
+	ErrUnknownAlgorithm = errors.New("password: unknown hash algorithm")
 )
 
-func Hash(plaintextPassword string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(plaintextPassword), 12)
-	if err != nil {
-		return "", err
+// Hasher hashes and verifies passwords with a single algorithm.
+type Hasher interface {
+	// Hash returns the encoded hash of plaintextPassword, including the
+	// algorithm identifier and its parameters.
+	Hash(plaintextPassword string) (string, error)
+
+	// Matches reports whether plaintextPassword matches hashedPassword.
+	Matches(plaintextPassword, hashedPassword string) (bool, error)
+
+	// Recognizes reports whether hashedPassword was produced by the algorithm of
+	// the Hasher.
+	Recognizes(hashedPassword string) bool
+
+	// NeedsRehash reports whether hashedPassword, which the Hasher recognizes,
+	// was produced with parameters other than the Hasher's.
+	NeedsRehash(hashedPassword string) bool
+}
+
+// Hashers hashes new passwords with a preferred Hasher while still verifying
+// hashes produced by the others.
+type Hashers struct {
+	preferred Hasher
+	legacy    []Hasher
+}
+
+// NewHashers returns Hashers which hash with preferred and verify with
+// preferred or any of legacy.
+func NewHashers(preferred Hasher, legacy ...Hasher) *Hashers {
+	return &Hashers{
+		preferred: preferred,
+		legacy:    legacy,
 	}
+}
+
+// Default hashes with argon2id and still verifies the bcrypt hashes created
+// before it was introduced.
+var Default = NewHashers(NewArgon2id(DefaultArgon2idParams), NewBcrypt(DefaultBcryptCost))
 
-	return string(hashedPassword), nil
+func (h *Hashers) Hash(plaintextPassword string) (string, error) {
+	return h.preferred.Hash(plaintextPassword)
 }
 
-func Matches(plaintextPassword, hashedPassword string) (bool, error) {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(plaintextPassword))
+// Matches identifies the algorithm from hashedPassword and verifies
+// plaintextPassword with it. ErrUnknownAlgorithm is returned when no Hasher
+// recognizes the hash.
+func (h *Hashers) Matches(plaintextPassword, hashedPassword string) (bool, error) {
+	hasher, err := h.find(hashedPassword)
 	if err != nil {
-		switch {
-		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
-			return false, nil
-		default:
-			return false, err
+		return false, err
+	}
+
+	return hasher.Matches(plaintextPassword, hashedPassword)
+}
+
+// NeedsRehash reports whether hashedPassword was produced by another algorithm
+// than the preferred one, or with other parameters. After a successful login
+// the password should then be hashed again and stored.
+func (h *Hashers) NeedsRehash(hashedPassword string) bool {
+	if !h.preferred.Recognizes(hashedPassword) {
+		return true
+	}
+
+	return h.preferred.NeedsRehash(hashedPassword)
+}
+
+func (h *Hashers) find(hashedPassword string) (Hasher, error) {
+	if h.preferred.Recognizes(hashedPassword) {
+		return h.preferred, nil
+	}
+
+	for _, hasher := range h.legacy {
+		if hasher.Recognizes(hashedPassword) {
+			return hasher, nil
 		}
 	}
 
-	return true, nil
+	return nil, ErrUnknownAlgorithm
+}
+
+func Hash(plaintextPassword string) (string, error) {
+	return Default.Hash(plaintextPassword)
+}
+
+func Matches(plaintextPassword, hashedPassword string) (bool, error) {
+	return Default.Matches(plaintextPassword, hashedPassword)
+}
+
+func NeedsRehash(hashedPassword string) bool {
+	return Default.NeedsRehash(hashedPassword)
 }
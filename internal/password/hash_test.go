@@ -0,0 +1,87 @@
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBcryptRoundTrip(t *testing.T) {
+	b := NewBcrypt(bcrypt.MinCost)
+
+	hash, err := b.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Recognizes(hash) {
+		t.Fatalf("Recognizes(%q) = false", hash)
+	}
+	if b.NeedsRehash(hash) {
+		t.Error("NeedsRehash() = true for the current cost")
+	}
+	if !NewBcrypt(bcrypt.MinCost + 1).NeedsRehash(hash) {
+		t.Error("NeedsRehash() = false for another cost")
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"correct horse battery staple", true},
+		{"correct horse battery stapler", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		ok, err := b.Matches(tt.password, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.password, ok, tt.want)
+		}
+	}
+}
+
+func TestHashersMigrateLegacyHashes(t *testing.T) {
+	argon := NewArgon2id(Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	legacy := NewBcrypt(bcrypt.MinCost)
+	h := NewHashers(argon, legacy)
+
+	legacyHash, err := legacy.Hash("secret password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentHash, err := h.Hash("secret password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		hash       string
+		wantMatch  bool
+		wantRehash bool
+		wantErr    error
+	}{
+		{"legacy", legacyHash, true, true, nil},
+		{"current", currentHash, true, false, nil},
+		{"unknown", "$md5$abc", false, true, ErrUnknownAlgorithm},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := h.Matches("secret password", tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Matches() error = %v, want %v", err, tt.wantErr)
+			}
+			if ok != tt.wantMatch {
+				t.Errorf("Matches() = %v, want %v", ok, tt.wantMatch)
+			}
+			if got := h.NeedsRehash(tt.hash); got != tt.wantRehash {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.wantRehash)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/grocky/go-api-starter/internal/validator"
+)
+
+// bcryptMaxBytes is the number of bytes bcrypt uses, anything beyond it is
+// silently ignored when hashing.
+const bcryptMaxBytes = 72
+
+// minSimilarityLength is the shortest piece of personal information which is
+// compared against a password. Shorter pieces, such as initials, match too many
+// passwords to be useful.
+const minSimilarityLength = 3
+
+// Codes reported for policy failures, in addition to the validator codes.
+const (
+	CodeTooCommon             = "too_common"
+	CodeTooSimilar            = "too_similar"
+	CodeMissingCharacterClass = "missing_character_class"
+	CodeBreached              = "breached"
+)
+
+// Character classes reported in the params of CodeMissingCharacterClass.
+const (
+	characterClassUppercase = "uppercase"
+	characterClassLowercase = "lowercase"
+	characterClassDigit     = "digit"
+	characterClassSymbol    = "symbol"
+)
+
+// Policy describes the rules a new password must satisfy.
+type Policy struct {
+	// MinLength is the minimum number of characters.
+	MinLength int
+	// MaxLength is the maximum number of bytes. It is capped at bcrypt's 72
+	// byte limit, so that no part of the password is ignored when hashing.
+	MaxLength int
+
+	// RejectCommon rejects the passwords in CommonPasswords.
+	RejectCommon bool
+
+	// Breaches, when set, rejects passwords which appear in a breach corpus at
+	// least BreachThreshold times. A threshold of zero or less rejects any
+	// appearance.
+	Breaches        BreachChecker
+	BreachThreshold int
+
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+}
+
+// DefaultPolicy follows the NIST 800-63B recommendations: a minimum length
+// and a blocklist of common passwords, without composition rules.
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength:    8,
+		MaxLength:    bcryptMaxBytes,
+		RejectCommon: true,
+	}
+}
+
+// Validate checks password against the policy and adds field errors for key to
+// v. related holds the user's personal information, such as their email
+// address and name, which the password must not contain. An error is only
+// returned when the breach corpus cannot be read.
+func (p Policy) Validate(v *validator.Validator, key, password string, related ...string) error {
+	if !validator.NotBlank(password) {
+		v.AddFieldMessage(key, validator.Msg("must be provided").WithCode(validator.CodeRequired))
+		return nil
+	}
+
+	maxLength := p.MaxLength
+	if maxLength <= 0 || maxLength > bcryptMaxBytes {
+		maxLength = bcryptMaxBytes
+	}
+
+	v.CheckFieldMessage(utf8.RuneCountInString(password) >= p.MinLength, key,
+		validator.Msg("must be at least %d characters long", p.MinLength).WithCode(validator.CodeTooShort, "min", p.MinLength))
+	v.CheckFieldMessage(len(password) <= maxLength, key,
+		validator.Msg("must not be more than %d bytes long", maxLength).WithCode(validator.CodeTooLong, "max", maxLength))
+
+	if p.RejectCommon {
+		v.CheckFieldMessage(!IsCommon(password), key,
+			validator.Msg("is too common").WithCode(CodeTooCommon))
+	}
+
+	v.CheckFieldMessage(!IsSimilar(password, related...), key,
+		validator.Msg("is too similar to your personal information").WithCode(CodeTooSimilar))
+
+	classes := []struct {
+		required bool
+		name     string
+		message  string
+		is       func(rune) bool
+	}{
+		{p.RequireUppercase, characterClassUppercase, "must contain at least one uppercase letter", unicode.IsUpper},
+		{p.RequireLowercase, characterClassLowercase, "must contain at least one lowercase letter", unicode.IsLower},
+		{p.RequireDigit, characterClassDigit, "must contain at least one digit", unicode.IsDigit},
+		{p.RequireSymbol, characterClassSymbol, "must contain at least one symbol", isSymbol},
+	}
+
+	for _, class := range classes {
+		if class.required {
+			v.CheckFieldMessage(strings.IndexFunc(password, class.is) >= 0, key,
+				validator.Msg(class.message).WithCode(CodeMissingCharacterClass, "class", class.name))
+		}
+	}
+
+	if p.Breaches != nil {
+		count, err := p.Breaches.Breached(password)
+		if err != nil {
+			return fmt.Errorf("password: breach lookup: %w", err)
+		}
+
+		threshold := max(p.BreachThreshold, 1)
+		v.CheckFieldMessage(count < threshold, key,
+			validator.Msg("has appeared in a data breach and must not be used").WithCode(CodeBreached, "count", count))
+	}
+
+	return nil
+}
+
+var commonPasswordSet = sync.OnceValue(func() map[string]struct{} {
+	set := make(map[string]struct{}, len(CommonPasswords))
+	for _, p := range CommonPasswords {
+		set[strings.ToLower(p)] = struct{}{}
+	}
+	return set
+})
+
+// IsCommon reports whether password, ignoring case, is in CommonPasswords.
+func IsCommon(password string) bool {
+	_, found := commonPasswordSet()[strings.ToLower(password)]
+	return found
+}
+
+// IsSimilar reports whether password contains, or is contained in, any piece of
+// the related personal information, ignoring case. Only the local part of email
+// addresses is compared, and it is split into words like names are.
+func IsSimilar(password string, related ...string) bool {
+	password = strings.ToLower(password)
+
+	for _, info := range related {
+		if local, _, isEmail := strings.Cut(info, "@"); isEmail {
+			info = local
+		}
+
+		for _, piece := range strings.FieldsFunc(strings.ToLower(info), isSeparator) {
+			if utf8.RuneCountInString(piece) < minSimilarityLength {
+				continue
+			}
+
+			if strings.Contains(password, piece) || strings.Contains(piece, password) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isSeparator(r rune) bool {
+	return r == '.' || r == '_' || r == '-' || r == '+' || unicode.IsSpace(r)
+}
+
+func isSymbol(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r)
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps sessions in memory. Sessions are lost on restart and are
+// not shared between instances, so it is meant for tests and development.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: map[string]Session{}}
+}
+
+func (m *MemoryStore) Get(_ context.Context, hash []byte) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[string(hash)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if !time.Now().Before(s.ExpiresAt) {
+		delete(m.sessions, string(hash))
+		return nil, ErrNotFound
+	}
+
+	return &s, nil
+}
+
+func (m *MemoryStore) Save(_ context.Context, s *Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *s
+	stored.Token = ""
+	m.sessions[string(s.Hash)] = stored
+
+	return nil
+}
+
+func (m *MemoryStore) Delete(_ context.Context, hash []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, string(hash))
+
+	return nil
+}
+
+func (m *MemoryStore) DeleteAllForUser(_ context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for hash, s := range m.sessions {
+		if s.UserID == userID {
+			delete(m.sessions, hash)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+// Package session manages cookie based sessions for browser clients.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var ErrNotFound = errors.New("session: not found")
+
+// touchInterval is the minimum time between two saves of the last activity of
+// a session, which saves a write on every request.
+const touchInterval = time.Minute
+
+// Session is an authenticated browser session. The token is only ever held by
+// the cookie, the store keeps its SHA-256 hash.
+type Session struct {
+	Token          string    `db:"-"`
+	Hash           []byte    `db:"hash"`
+	UserID         string    `db:"user_id"`
+	CSRFToken      string    `db:"csrf_token"`
+	CreatedAt      time.Time `db:"created_at"`
+	LastActivityAt time.Time `db:"last_activity_at"`
+	ExpiresAt      time.Time `db:"expires_at"`
+}
+
+// Store persists sessions by the hash of their token.
+type Store interface {
+	// Get returns the unexpired session with the hash, or ErrNotFound.
+	Get(ctx context.Context, hash []byte) (*Session, error)
+	// Save inserts or updates the session.
+	Save(ctx context.Context, s *Session) error
+	Delete(ctx context.Context, hash []byte) error
+	DeleteAllForUser(ctx context.Context, userID string) error
+}
+
+// Manager creates and loads sessions from cookies. A session ends after
+// IdleTimeout without activity, or AbsoluteTimeout after it was created,
+// whichever comes first.
+type Manager struct {
+	Store           Store
+	CookieName      string
+	IdleTimeout     time.Duration
+	AbsoluteTimeout time.Duration
+
+	// Secure restricts the cookie to HTTPS. It should only be disabled for
+	// local development over plain HTTP.
+	Secure bool
+}
+
+// NewManager returns a Manager with a 30 minute idle timeout, a 12 hour
+// absolute timeout and a secure cookie.
+func NewManager(store Store, secure bool) *Manager {
+	name := "session"
+	if secure {
+		// The __Host- prefix makes browsers refuse the cookie unless it is
+		// secure, host-only and scoped to the whole site.
+		name = "__Host-session"
+	}
+
+	return &Manager{
+		Store:           store,
+		CookieName:      name,
+		IdleTimeout:     30 * time.Minute,
+		AbsoluteTimeout: 12 * time.Hour,
+		Secure:          secure,
+	}
+}
+
+// Renew starts a new session for the user and sets its cookie. The session of
+// the request, if any, is destroyed, so that a session token planted before
+// login cannot be used afterwards.
+func (m *Manager) Renew(ctx context.Context, w http.ResponseWriter, r *http.Request, userID string) (*Session, error) {
+	if cookie, err := r.Cookie(m.CookieName); err == nil {
+		if err := m.Store.Delete(ctx, hashToken(cookie.Value)); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	csrfToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	s := &Session{
+		Token:          token,
+		Hash:           hashToken(token),
+		UserID:         userID,
+		CSRFToken:      csrfToken,
+		CreatedAt:      now,
+		LastActivityAt: now,
+	}
+	s.ExpiresAt = m.expiry(s)
+
+	if err := m.Store.Save(ctx, s); err != nil {
+		return nil, err
+	}
+
+	m.setCookie(w, s.Token, s.ExpiresAt)
+
+	return s, nil
+}
+
+// Load returns the session of the request cookie and extends it, or
+// ErrNotFound when there is no cookie or the session has expired.
+func (m *Manager) Load(ctx context.Context, w http.ResponseWriter, r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(m.CookieName)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	s, err := m.Store.Get(ctx, hashToken(cookie.Value))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			m.setCookie(w, "", time.Unix(0, 0))
+		}
+		return nil, err
+	}
+	s.Token = cookie.Value
+
+	now := time.Now().UTC()
+	if !now.Before(s.ExpiresAt) {
+		m.setCookie(w, "", time.Unix(0, 0))
+		return nil, ErrNotFound
+	}
+
+	if now.Sub(s.LastActivityAt) >= touchInterval {
+		s.LastActivityAt = now
+		s.ExpiresAt = m.expiry(s)
+
+		if err := m.Store.Save(ctx, s); err != nil {
+			return nil, err
+		}
+		m.setCookie(w, s.Token, s.ExpiresAt)
+	}
+
+	return s, nil
+}
+
+// Destroy ends the session of the request and clears its cookie.
+func (m *Manager) Destroy(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	m.setCookie(w, "", time.Unix(0, 0))
+
+	cookie, err := r.Cookie(m.CookieName)
+	if err != nil {
+		return nil
+	}
+
+	return m.Store.Delete(ctx, hashToken(cookie.Value))
+}
+
+func (m *Manager) expiry(s *Session) time.Time {
+	idle := s.LastActivityAt.Add(m.IdleTimeout)
+	absolute := s.CreatedAt.Add(m.AbsoluteTimeout)
+
+	if idle.Before(absolute) {
+		return idle
+	}
+	return absolute
+}
+
+func (m *Manager) setCookie(w http.ResponseWriter, value string, expires time.Time) {
+	cookie := &http.Cookie{
+		Name:     m.CookieName,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   m.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if value == "" {
+		cookie.MaxAge = -1
+	}
+
+	http.SetCookie(w, cookie)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
@@ -0,0 +1,253 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// responseCookie returns the cookie named name set on the response, if any.
+func responseCookie(w *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// requestWithCookie returns a request carrying the session cookie of m, unless
+// token is empty.
+func requestWithCookie(m *Manager, token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		r.AddCookie(&http.Cookie{Name: m.CookieName, Value: token})
+	}
+	return r
+}
+
+func TestNewManagerCookieName(t *testing.T) {
+	tests := []struct {
+		secure bool
+		want   string
+	}{
+		{true, "__Host-session"},
+		{false, "session"},
+	}
+
+	for _, tt := range tests {
+		if got := NewManager(NewMemoryStore(), tt.secure).CookieName; got != tt.want {
+			t.Errorf("NewManager(secure %v).CookieName = %q, want %q", tt.secure, got, tt.want)
+		}
+	}
+}
+
+func TestManagerRenew(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	m := NewManager(store, true)
+
+	first, err := m.Renew(ctx, httptest.NewRecorder(), requestWithCookie(m, ""), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Renewing from the first session rotates its token and CSRF token.
+	w := httptest.NewRecorder()
+	second, err := m.Renew(ctx, w, requestWithCookie(m, first.Token), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if second.Token == first.Token {
+		t.Error("Renew() kept the session token")
+	}
+	if second.CSRFToken == first.CSRFToken {
+		t.Error("Renew() kept the CSRF token")
+	}
+	if _, err := store.Get(ctx, hashToken(first.Token)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("previous session Get() error = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Get(ctx, hashToken(second.Token)); err != nil {
+		t.Errorf("new session Get() error = %v", err)
+	}
+
+	c := responseCookie(w, m.CookieName)
+	switch {
+	case c == nil:
+		t.Fatal("Renew() set no cookie")
+	case c.Value != second.Token:
+		t.Errorf("cookie = %q, want the new token %q", c.Value, second.Token)
+	case !c.Secure || !c.HttpOnly || c.SameSite != http.SameSiteLaxMode || c.Path != "/":
+		t.Errorf("cookie = %+v, want secure, HTTP only, lax and site wide", c)
+	}
+	if want := second.CreatedAt.Add(m.IdleTimeout); !second.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want the idle timeout %v", second.ExpiresAt, want)
+	}
+}
+
+func TestManagerLoad(t *testing.T) {
+	now := time.Now().UTC()
+	idle, absolute := 30*time.Minute, 12*time.Hour
+
+	tests := []struct {
+		name         string
+		created      time.Time
+		lastActivity time.Time
+		cookie       bool
+		wantErr      error
+		wantCleared  bool
+		wantTouched  bool
+		wantExpiry   time.Time
+	}{
+		{
+			name:         "recently active",
+			created:      now.Add(-time.Hour),
+			lastActivity: now.Add(-10 * time.Second),
+			cookie:       true,
+			wantExpiry:   now.Add(-10 * time.Second).Add(idle),
+		},
+		{
+			name:         "touched",
+			created:      now.Add(-time.Hour),
+			lastActivity: now.Add(-10 * time.Minute),
+			cookie:       true,
+			wantTouched:  true,
+			wantExpiry:   now.Add(idle),
+		},
+		{
+			name:         "touched up to the absolute timeout",
+			created:      now.Add(-absolute + 5*time.Minute),
+			lastActivity: now.Add(-10 * time.Minute),
+			cookie:       true,
+			wantTouched:  true,
+			wantExpiry:   now.Add(-absolute + 5*time.Minute).Add(absolute),
+		},
+		{
+			name:         "idle expired",
+			created:      now.Add(-time.Hour),
+			lastActivity: now.Add(-idle - time.Second),
+			cookie:       true,
+			wantErr:      ErrNotFound,
+			wantCleared:  true,
+		},
+		{
+			name:         "absolute expired",
+			created:      now.Add(-absolute - time.Second),
+			lastActivity: now.Add(-time.Minute),
+			cookie:       true,
+			wantErr:      ErrNotFound,
+			wantCleared:  true,
+		},
+		{
+			name:    "no cookie",
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemoryStore()
+			m := NewManager(store, true)
+
+			s := &Session{
+				Token:          "token",
+				Hash:           hashToken("token"),
+				UserID:         "user-1",
+				CSRFToken:      "csrf",
+				CreatedAt:      tt.created,
+				LastActivityAt: tt.lastActivity,
+			}
+			s.ExpiresAt = m.expiry(s)
+			if err := store.Save(ctx, s); err != nil {
+				t.Fatal(err)
+			}
+
+			token := ""
+			if tt.cookie {
+				token = s.Token
+			}
+
+			w := httptest.NewRecorder()
+			got, err := m.Load(ctx, w, requestWithCookie(m, token))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+			}
+
+			c := responseCookie(w, m.CookieName)
+			if cleared := c != nil && c.MaxAge < 0; cleared != tt.wantCleared {
+				t.Errorf("cookie cleared = %v, want %v", cleared, tt.wantCleared)
+			}
+			if err != nil {
+				return
+			}
+
+			if got.Token != s.Token || got.UserID != s.UserID {
+				t.Errorf("Load() = token %q of %q, want %q of %q", got.Token, got.UserID, s.Token, s.UserID)
+			}
+			if touched := c != nil && c.Value == s.Token; touched != tt.wantTouched {
+				t.Errorf("cookie refreshed = %v, want %v", touched, tt.wantTouched)
+			}
+			if d := got.ExpiresAt.Sub(tt.wantExpiry).Abs(); d > time.Second {
+				t.Errorf("ExpiresAt = %v, want %v", got.ExpiresAt, tt.wantExpiry)
+			}
+
+			stored, err := store.Get(ctx, s.Hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !stored.ExpiresAt.Equal(got.ExpiresAt) {
+				t.Errorf("stored ExpiresAt = %v, want %v", stored.ExpiresAt, got.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestManagerDestroy(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie bool
+	}{
+		{"with a session", true},
+		{"without a cookie", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewMemoryStore()
+			m := NewManager(store, true)
+
+			s, err := m.Renew(ctx, httptest.NewRecorder(), requestWithCookie(m, ""), "user-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token := ""
+			if tt.cookie {
+				token = s.Token
+			}
+
+			w := httptest.NewRecorder()
+			if err := m.Destroy(ctx, w, requestWithCookie(m, token)); err != nil {
+				t.Fatal(err)
+			}
+
+			if c := responseCookie(w, m.CookieName); c == nil || c.Value != "" || c.MaxAge >= 0 {
+				t.Errorf("cookie = %+v, want it cleared", c)
+			}
+
+			_, err = store.Get(ctx, s.Hash)
+			if destroyed := errors.Is(err, ErrNotFound); destroyed != tt.cookie {
+				t.Errorf("session destroyed = %v, want %v", destroyed, tt.cookie)
+			}
+			if _, err := m.Load(ctx, httptest.NewRecorder(), requestWithCookie(m, s.Token)); tt.cookie && !errors.Is(err, ErrNotFound) {
+				t.Errorf("Load() after Destroy() error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package smtp
 
 import (
 	"bytes"
+	"context"
 	"html/template"
 	"time"
 
@@ -26,55 +27,92 @@ func NewMailer(host string, port int, username, password, from string) *Mailer {
 	}
 }
 
+// Message is a rendered email, which can be stored and delivered later.
+type Message struct {
+	To        string `json:"to"`
+	Subject   string `json:"subject"`
+	PlainBody string `json:"plain_body"`
+	HTMLBody  string `json:"html_body,omitempty"`
+}
+
+// Send renders the email templates and delivers the message.
 func (m *Mailer) Send(recipient string, data any, patterns ...string) error {
+	msg, err := m.Render(recipient, data, patterns...)
+	if err != nil {
+		return err
+	}
+
+	return m.Deliver(context.Background(), msg)
+}
+
+// Render executes the "subject", "plainBody" and optional "htmlBody" templates
+// of the email templates matching patterns with data.
+func (m *Mailer) Render(recipient string, data any, patterns ...string) (*Message, error) {
 	for i := range patterns {
 		patterns[i] = "emails/" + patterns[i]
 	}
 
-	msg := mail.NewMessage()
-	msg.SetHeader("To", recipient)
-	msg.SetHeader("From", m.from)
-
 	ts, err := template.New("").Funcs(funcs.TemplateFuncs).ParseFS(assets.EmbeddedFiles, patterns...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	subject := new(bytes.Buffer)
 	err = ts.ExecuteTemplate(subject, "subject", data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	msg.SetHeader("Subject", subject.String())
-
 	plainBody := new(bytes.Buffer)
 	err = ts.ExecuteTemplate(plainBody, "plainBody", data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	msg.SetBody("text/plain", plainBody.String())
+	msg := &Message{
+		To:        recipient,
+		Subject:   subject.String(),
+		PlainBody: plainBody.String(),
+	}
 
 	if ts.Lookup("htmlBody") != nil {
 		htmlBody := new(bytes.Buffer)
 		err = ts.ExecuteTemplate(htmlBody, "htmlBody", data)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		msg.AddAlternative("text/html", htmlBody.String())
+		msg.HTMLBody = htmlBody.String()
 	}
 
-	for i := 1; i <= 3; i++ {
-		err = m.dialer.DialAndSend(msg)
-
-		if nil == err {
-			return nil
-		}
+	return msg, nil
+}
 
-		time.Sleep(2 * time.Second)
+// Deliver makes a single attempt at sending the message, retries being left
+// to the caller. It returns once ctx is done without waiting for the SMTP
+// exchange, which may still complete afterwards.
+func (m *Mailer) Deliver(ctx context.Context, msg *Message) error {
+	mailMsg := mail.NewMessage()
+	mailMsg.SetHeader("To", msg.To)
+	mailMsg.SetHeader("From", m.from)
+	mailMsg.SetHeader("Subject", msg.Subject)
+	mailMsg.SetBody("text/plain", msg.PlainBody)
+
+	if msg.HTMLBody != "" {
+		mailMsg.AddAlternative("text/html", msg.HTMLBody)
 	}
 
-	return err
+	// The dialer takes no context, so the exchange is left to finish or
+	// time out in the background when ctx is done first.
+	done := make(chan error, 1)
+	go func() {
+		done <- m.dialer.DialAndSend(mailMsg)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
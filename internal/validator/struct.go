@@ -0,0 +1,331 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RuleFunc checks a single field value against a rule. param is the text
+// following "=" in the tag, or empty if there is none. When the value is
+// invalid the rule returns false and the message reported for the field, which
+// is translated by the Validator's Printer. Rules should give the message a
+// code with Message.WithCode.
+type RuleFunc func(value reflect.Value, param string) (bool, Message)
+
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]RuleFunc{
+		"required": ruleRequired,
+		"email":    ruleEmail,
+		"url":      ruleURL,
+		"min":      ruleMin,
+		"max":      ruleMax,
+		"oneof":    ruleOneOf,
+		"unique":   ruleUnique,
+	}
+)
+
+// RegisterRule makes a custom rule available to `validate` tags under name.
+// It panics if name is empty, contains tag syntax or is already registered.
+func RegisterRule(name string, fn RuleFunc) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+
+	if name == "" || strings.ContainsAny(name, ",=") || name == "omitempty" {
+		panic("validator: invalid rule name " + strconv.Quote(name))
+	}
+	if fn == nil {
+		panic("validator: RegisterRule fn is nil")
+	}
+	if _, dup := rules[name]; dup {
+		panic("validator: RegisterRule called twice for rule " + name)
+	}
+
+	rules[name] = fn
+}
+
+// CheckStruct validates the exported fields of the struct s, or pointer to a
+// struct, using their `validate` tags. Rules are separated by commas and
+// parameters follow an equals sign:
+//
+//	Email string   `json:"email" validate:"required,email,max=255"`
+//	Tags  []string `json:"tags" validate:"max=5,unique"`
+//	Role  string   `json:"role" validate:"oneof=admin user"`
+//
+// Nested structs, pointers to structs and slices of structs are walked
+// recursively. Field errors are keyed by the JSON field name, with nested
+// fields joined as "address.city" and slice elements as "items[2].email", see
+// Path. Every failing rule of a field is recorded. The omitempty rule skips the
+// remaining rules when the value is empty.
+func (v *Validator) CheckStruct(s any) {
+	rv := reflect.ValueOf(s)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validator: CheckStruct expects a struct, got %T", s))
+	}
+
+	v.checkStruct(rv, "")
+}
+
+func (v *Validator) checkStruct(rv reflect.Value, prefix string) {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+
+		if field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			if fv = indirect(fv); fv.IsValid() {
+				v.checkStruct(fv, prefix)
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		key := Path(prefix, name)
+
+		v.checkField(fv, key, field.Tag.Get("validate"))
+		v.checkNested(fv, key)
+	}
+}
+
+func (v *Validator) checkField(fv reflect.Value, key, tag string) {
+	if tag == "" || tag == "-" {
+		return
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		if name == "omitempty" {
+			if isEmpty(fv) {
+				return
+			}
+			continue
+		}
+
+		rulesMu.RLock()
+		fn, ok := rules[name]
+		rulesMu.RUnlock()
+
+		if !ok {
+			panic("validator: unknown rule " + strconv.Quote(name) + " on field " + key)
+		}
+
+		if ok, msg := fn(fv, param); !ok {
+			v.AddFieldMessage(key, msg)
+		}
+	}
+}
+
+// checkNested walks into struct values and slices of struct values.
+func (v *Validator) checkNested(fv reflect.Value, key string) {
+	fv = indirect(fv)
+	if !fv.IsValid() {
+		return
+	}
+
+	switch fv.Kind() {
+	case reflect.Struct:
+		if fv.Type() == reflect.TypeOf(time.Time{}) {
+			return
+		}
+		v.checkStruct(fv, key)
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			elem := indirect(fv.Index(i))
+			if elem.IsValid() && elem.Kind() == reflect.Struct && elem.Type() != reflect.TypeOf(time.Time{}) {
+				v.checkStruct(elem, Path(key, i))
+			}
+		}
+	}
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// indirect dereferences pointers, returning an invalid value for nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.String:
+		return !NotBlank(v.String())
+	}
+	return !v.IsValid() || v.IsZero()
+}
+
+// ruleRequired rejects nil pointers as well as pointers to an empty value.
+func ruleRequired(v reflect.Value, _ string) (bool, Message) {
+	return !isEmpty(indirect(v)), Msg("must be provided").WithCode(CodeRequired)
+}
+
+func ruleEmail(v reflect.Value, _ string) (bool, Message) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return true, Message{}
+	}
+	return IsEmail(v.String()), Msg("must be a valid email address").WithCode(CodeInvalidEmail)
+}
+
+func ruleURL(v reflect.Value, _ string) (bool, Message) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return true, Message{}
+	}
+	return IsURL(v.String()), Msg("must be a valid URL").WithCode(CodeInvalidURL)
+}
+
+func ruleMin(v reflect.Value, param string) (bool, Message) {
+	limit := sizeParam(param)
+
+	v = indirect(v)
+	if !v.IsValid() {
+		return true, Message{}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return MinRunes(v.String(), int(limit)), Msg("must be at least %d characters long", int(limit)).WithCode(CodeTooShort, "min", int(limit))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() >= int(limit), Msg("must contain at least %d items", int(limit)).WithCode(CodeTooFew, "min", int(limit))
+	}
+
+	return number(v) >= limit, Msg("must be at least %v", limit).WithCode(CodeTooSmall, "min", limit)
+}
+
+func ruleMax(v reflect.Value, param string) (bool, Message) {
+	limit := sizeParam(param)
+
+	v = indirect(v)
+	if !v.IsValid() {
+		return true, Message{}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return MaxRunes(v.String(), int(limit)), Msg("must not be more than %d characters long", int(limit)).WithCode(CodeTooLong, "max", int(limit))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() <= int(limit), Msg("must not contain more than %d items", int(limit)).WithCode(CodeTooMany, "max", int(limit))
+	}
+
+	return number(v) <= limit, Msg("must not be more than %v", limit).WithCode(CodeTooLarge, "max", limit)
+}
+
+func sizeParam(param string) float64 {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic("validator: invalid size parameter " + strconv.Quote(param))
+	}
+	return limit
+}
+
+func number(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+
+	panic("validator: min and max do not support " + v.Type().String())
+}
+
+func ruleOneOf(v reflect.Value, param string) (bool, Message) {
+	safelist := strings.Fields(param)
+	msg := Msg("must be one of %s", strings.Join(safelist, ", ")).WithCode(CodeInvalidChoice, "values", safelist)
+
+	v = indirect(v)
+	if !v.IsValid() {
+		return true, Message{}
+	}
+
+	if v.Kind() == reflect.Slice {
+		values := make([]string, v.Len())
+		for i := range values {
+			values[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return AllIn(values, safelist...), msg
+	}
+
+	return In(fmt.Sprint(v.Interface()), safelist...), msg
+}
+
+func ruleUnique(v reflect.Value, _ string) (bool, Message) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return true, Message{}
+	}
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		panic("validator: unique does not support " + v.Type().String())
+	}
+
+	msg := Msg("must not contain duplicate values").WithCode(CodeDuplicate)
+
+	values := make([]any, v.Len())
+	comparable := true
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+		comparable = comparable && reflect.ValueOf(values[i]).Comparable()
+	}
+
+	if comparable {
+		return NoDuplicates(values), msg
+	}
+
+	// Values such as slices and maps cannot be map keys, so they are compared
+	// with each other instead.
+	for i := range values {
+		for j := i + 1; j < len(values); j++ {
+			if reflect.DeepEqual(values[i], values[j]) {
+				return false, msg
+			}
+		}
+	}
+
+	return true, msg
+}
@@ -0,0 +1,164 @@
+package validator
+
+import (
+	"slices"
+	"testing"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type item struct {
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+type checked struct {
+	Name     string   `json:"name" validate:"required,min=2,max=5"`
+	Nickname *string  `json:"nickname" validate:"required"`
+	Website  string   `json:"website" validate:"omitempty,url"`
+	Age      int      `json:"age" validate:"min=18,max=130"`
+	Role     string   `json:"role" validate:"oneof=admin user"`
+	Tags     []string `json:"tags" validate:"max=3,unique"`
+	Matrix   [][]int  `json:"matrix" validate:"unique"`
+	Options  []any    `json:"options" validate:"unique"`
+	Address  address  `json:"address"`
+	Items    []item   `json:"items"`
+	Manager  *address `json:"manager"`
+	Ignored  string   `json:"-" validate:"required"`
+	internal string   `validate:"required"`
+}
+
+func valid() checked {
+	nickname := "bob"
+	return checked{
+		Name:     "Bob",
+		Nickname: &nickname,
+		Age:      30,
+		Role:     "user",
+		Tags:     []string{"a", "b"},
+		Matrix:   [][]int{{1}, {2}},
+		Options:  []any{1, []int{1}, map[string]int{"a": 1}},
+		Address:  address{City: "Paris"},
+		Items:    []item{{Email: "bob@example.com"}, {}},
+	}
+}
+
+func TestCheckStruct(t *testing.T) {
+	empty := ""
+
+	tests := []struct {
+		name   string
+		modify func(c *checked)
+		want   map[string][]string
+	}{
+		{
+			name:   "valid",
+			modify: func(*checked) {},
+		},
+		{
+			name:   "required",
+			modify: func(c *checked) { c.Name = " " },
+			want:   map[string][]string{"name": {CodeRequired, CodeTooShort}},
+		},
+		{
+			name:   "required nil pointer",
+			modify: func(c *checked) { c.Nickname = nil },
+			want:   map[string][]string{"nickname": {CodeRequired}},
+		},
+		{
+			name:   "required pointer to empty",
+			modify: func(c *checked) { c.Nickname = &empty },
+			want:   map[string][]string{"nickname": {CodeRequired}},
+		},
+		{
+			name:   "too long",
+			modify: func(c *checked) { c.Name = "Robert" },
+			want:   map[string][]string{"name": {CodeTooLong}},
+		},
+		{
+			name:   "omitempty skips invalid url",
+			modify: func(c *checked) { c.Website = "" },
+		},
+		{
+			name:   "invalid url",
+			modify: func(c *checked) { c.Website = "not a url" },
+			want:   map[string][]string{"website": {CodeInvalidURL}},
+		},
+		{
+			name:   "too small",
+			modify: func(c *checked) { c.Age = 12 },
+			want:   map[string][]string{"age": {CodeTooSmall}},
+		},
+		{
+			name:   "invalid choice",
+			modify: func(c *checked) { c.Role = "root" },
+			want:   map[string][]string{"role": {CodeInvalidChoice}},
+		},
+		{
+			name:   "too many duplicates",
+			modify: func(c *checked) { c.Tags = []string{"a", "b", "c", "a"} },
+			want:   map[string][]string{"tags": {CodeTooMany, CodeDuplicate}},
+		},
+		{
+			name:   "duplicate non-comparable elements",
+			modify: func(c *checked) { c.Matrix = [][]int{{1, 2}, {1, 2}} },
+			want:   map[string][]string{"matrix": {CodeDuplicate}},
+		},
+		{
+			name:   "duplicate non-comparable dynamic values",
+			modify: func(c *checked) { c.Options = []any{1, []int{1}, []int{1}} },
+			want:   map[string][]string{"options": {CodeDuplicate}},
+		},
+		{
+			name:   "nested struct",
+			modify: func(c *checked) { c.Address.City = "" },
+			want:   map[string][]string{"address.city": {CodeRequired}},
+		},
+		{
+			name:   "slice of structs",
+			modify: func(c *checked) { c.Items[1].Email = "bob" },
+			want:   map[string][]string{"items[1].email": {CodeInvalidEmail}},
+		},
+		{
+			name:   "pointer to struct",
+			modify: func(c *checked) { c.Manager = &address{} },
+			want:   map[string][]string{"manager.city": {CodeRequired}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.modify(&c)
+
+			var v Validator
+			v.CheckStruct(&c)
+
+			got := map[string][]string{}
+			for key, errs := range v.FieldErrorDetails {
+				for _, fe := range errs {
+					got[key] = append(got[key], fe.Code)
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("field errors = %v, want %v", got, tt.want)
+			}
+			for key, codes := range tt.want {
+				if !slices.Equal(got[key], codes) {
+					t.Errorf("field %q codes = %v, want %v", key, got[key], codes)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckStructNil(t *testing.T) {
+	var v Validator
+	v.CheckStruct((*checked)(nil))
+
+	if v.HasErrors() {
+		t.Errorf("CheckStruct(nil) errors = %v", v.FieldErrors)
+	}
+}
@@ -1,14 +1,124 @@
 package validator
 
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Codes identify the kind of a field error independently of the, possibly
+// translated, message so that clients can key their own text on them.
+const (
+	CodeInvalid       = "invalid"
+	CodeRequired      = "required"
+	CodeInvalidEmail  = "invalid_email"
+	CodeInvalidURL    = "invalid_url"
+	CodeInvalidType   = "invalid_type"
+	CodeInvalidChoice = "invalid_choice"
+	CodeTooShort      = "too_short"
+	CodeTooLong       = "too_long"
+	CodeTooFew        = "too_few"
+	CodeTooMany       = "too_many"
+	CodeTooSmall      = "too_small"
+	CodeTooLarge      = "too_large"
+	CodeDuplicate     = "duplicate"
+	CodeAlreadyExists = "already_exists"
+)
+
+// Validator collects validation errors. FieldErrors holds the first message of
+// every field, as it always has, while FieldErrorDetails holds every error of
+// every field along with its code and parameters.
 type Validator struct {
-	Errors      []string          `json:",omitempty"`
-	FieldErrors map[string]string `json:",omitempty"`
+	Errors            []string                `json:",omitempty"`
+	FieldErrors       map[string]string       `json:",omitempty"`
+	FieldErrorDetails map[string][]FieldError `json:",omitempty"`
+
+	// Printer translates the messages added with the formatted methods, such
+	// as CheckFieldf. Messages are formatted in English when it is nil.
+	Printer *message.Printer `json:"-"`
+}
+
+// FieldError is a single failure of a field.
+type FieldError struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Params  map[string]any `json:"params,omitempty"`
+}
+
+// New returns a Validator which translates its messages with p.
+func New(p *message.Printer) Validator {
+	return Validator{Printer: p}
+}
+
+// Message is a message format and its arguments, along with the code and
+// parameters reported to clients. The format is the key used to look up the
+// translation.
+type Message struct {
+	Format string
+	Args   []any
+	Code   string
+	Params map[string]any
+}
+
+// Msg returns a Message from the format and its arguments with the generic
+// CodeInvalid code.
+func Msg(format string, args ...any) Message {
+	return Message{Format: format, Args: args, Code: CodeInvalid}
+}
+
+// WithCode returns a copy of m with the given code. params are alternating
+// names and values, in the style of log attributes:
+//
+//	Msg("must not be more than %d characters long", 255).WithCode(CodeTooLong, "max", 255)
+func (m Message) WithCode(code string, params ...any) Message {
+	m.Code = code
+	m.Params = nil
+
+	if len(params) > 0 {
+		m.Params = make(map[string]any, len(params)/2)
+		for i := 0; i+1 < len(params); i += 2 {
+			m.Params[fmt.Sprint(params[i])] = params[i+1]
+		}
+	}
+
+	return m
+}
+
+// Path joins field names and slice indexes into a nested field key, for
+// example Path("items", 2, "email") returns "items[2].email".
+func Path(elems ...any) string {
+	var b strings.Builder
+
+	for _, elem := range elems {
+		switch e := elem.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%d]", e)
+		default:
+			s := fmt.Sprint(e)
+			if s == "" {
+				continue
+			}
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(s)
+		}
+	}
+
+	return b.String()
 }
 
 func (v Validator) HasErrors() bool {
 	return len(v.Errors) != 0 || len(v.FieldErrors) != 0
 }
 
+// Field returns every error recorded for key.
+func (v Validator) Field(key string) []FieldError {
+	return v.FieldErrorDetails[key]
+}
+
 func (v *Validator) AddError(message string) {
 	if v.Errors == nil {
 		v.Errors = []string{}
@@ -17,14 +127,9 @@ func (v *Validator) AddError(message string) {
 	v.Errors = append(v.Errors, message)
 }
 
+// AddFieldError adds message as an error for key with the CodeInvalid code.
 func (v *Validator) AddFieldError(key, message string) {
-	if v.FieldErrors == nil {
-		v.FieldErrors = map[string]string{}
-	}
-
-	if _, exists := v.FieldErrors[key]; !exists {
-		v.FieldErrors[key] = message
-	}
+	v.addFieldError(key, FieldError{Code: CodeInvalid, Message: message})
 }
 
 func (v *Validator) Check(ok bool, message string) {
@@ -38,3 +143,95 @@ func (v *Validator) CheckField(ok bool, key, message string) {
 		v.AddFieldError(key, message)
 	}
 }
+
+// AddErrorf translates format and adds it as an error.
+func (v *Validator) AddErrorf(format string, args ...any) {
+	v.AddError(v.sprintf(format, args...))
+}
+
+// AddFieldErrorf translates format and adds it as an error for key.
+func (v *Validator) AddFieldErrorf(key, format string, args ...any) {
+	v.AddFieldMessage(key, Msg(format, args...))
+}
+
+func (v *Validator) Checkf(ok bool, format string, args ...any) {
+	if !ok {
+		v.AddErrorf(format, args...)
+	}
+}
+
+func (v *Validator) CheckFieldf(ok bool, key, format string, args ...any) {
+	if !ok {
+		v.AddFieldErrorf(key, format, args...)
+	}
+}
+
+// AddFieldMessage translates m and adds it as an error for key, keeping its
+// code and parameters.
+func (v *Validator) AddFieldMessage(key string, m Message) {
+	code := m.Code
+	if code == "" {
+		code = CodeInvalid
+	}
+
+	v.addFieldError(key, FieldError{
+		Code:    code,
+		Message: v.sprintf(m.Format, m.Args...),
+		Params:  m.Params,
+	})
+}
+
+func (v *Validator) CheckFieldMessage(ok bool, key string, m Message) {
+	if !ok {
+		v.AddFieldMessage(key, m)
+	}
+}
+
+// Merge adds the errors of other to v, nesting its field keys under prefix.
+// Errors which are not tied to a field are added to the field named prefix,
+// or to v's errors when prefix is empty.
+func (v *Validator) Merge(prefix string, other Validator) {
+	for _, message := range other.Errors {
+		if prefix == "" {
+			v.AddError(message)
+		} else {
+			v.AddFieldError(prefix, message)
+		}
+	}
+
+	for key, errs := range other.FieldErrorDetails {
+		for _, fe := range errs {
+			v.addFieldError(Path(prefix, key), fe)
+		}
+	}
+}
+
+func (v *Validator) addFieldError(key string, fe FieldError) {
+	if v.FieldErrors == nil {
+		v.FieldErrors = map[string]string{}
+	}
+	if v.FieldErrorDetails == nil {
+		v.FieldErrorDetails = map[string][]FieldError{}
+	}
+
+	if _, exists := v.FieldErrors[key]; !exists {
+		v.FieldErrors[key] = fe.Message
+	}
+
+	for _, existing := range v.FieldErrorDetails[key] {
+		if existing.Code == fe.Code && existing.Message == fe.Message {
+			return
+		}
+	}
+
+	v.FieldErrorDetails[key] = append(v.FieldErrorDetails[key], fe)
+}
+
+func (v *Validator) sprintf(format string, args ...any) string {
+	p := v.Printer
+	if p == nil {
+		p = message.NewPrinter(language.English)
+	}
+
+	return p.Sprintf(format, args...)
+}